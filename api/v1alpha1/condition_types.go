@@ -13,11 +13,52 @@ const (
 	// ControlPlaneInitializedCondition defines the initialized condition type if the vcluster is reachable.
 	ControlPlaneInitializedCondition ConditionType = "ControlPlaneInitialized"
 
+	// ControlPlaneLiveCondition defines if the api server process of the control plane is up, it is only
+	// set if a startup probe path is configured.
+	ControlPlaneLiveCondition ConditionType = "ControlPlaneLive"
+
+	// ControlPlaneWorkloadsReadyCondition defines if all statefulsets and deployments of the vcluster release
+	// have their replicas ready, it is only set if the workload check is enabled.
+	ControlPlaneWorkloadsReadyCondition ConditionType = "ControlPlaneWorkloadsReady"
+
 	// KubeconfigReadyCondition defines the ready condition type if the vcluster kubeconfig was written.
 	KubeconfigReadyCondition ConditionType = "KubeconfigReady"
 
 	// HelmChartDeployedCondition defines the helm chart deployed condition type that defines if the helm chart was deployed correctly.
 	HelmChartDeployedCondition ConditionType = "HelmChartDeployed"
+
+	// NetworkPolicyReadyCondition defines if the network policies isolating the vcluster namespace were reconciled.
+	NetworkPolicyReadyCondition ConditionType = "NetworkPolicyReady"
+
+	// LimitRangeReadyCondition defines if the limit range of the vcluster namespace was reconciled.
+	LimitRangeReadyCondition ConditionType = "LimitRangeReady"
+
+	// HelmHooksSucceededCondition defines if the helm hooks of the last install / upgrade succeeded.
+	HelmHooksSucceededCondition ConditionType = "HelmHooksSucceeded"
+
+	// StalledReconcileCondition is true when the controller could not reconcile the current generation
+	// for longer than the configured stalled timeout.
+	StalledReconcileCondition ConditionType = "StalledReconcile"
+
+	// DistroValuesValidCondition defines if the helm values only contain keys that are used by the distro of the chart.
+	DistroValuesValidCondition ConditionType = "DistroValuesValid"
+
+	// KubernetesVersionSupportedCondition defines if the requested kubernetes version is supported by the helm chart.
+	KubernetesVersionSupportedCondition ConditionType = "KubernetesVersionSupported"
+
+	// CleanupSucceededCondition reports the current cleanup stage of a deleted vcluster in its reason.
+	CleanupSucceededCondition ConditionType = "CleanupSucceeded"
+
+	// ValuesSchemaValidCondition defines if the helm values are valid according to the values schema of the chart.
+	ValuesSchemaValidCondition ConditionType = "ValuesSchemaValid"
+
+	// DriftDetectedCondition is true when the values of the deployed helm release differ from the
+	// desired values, e.g. because of a helm upgrade outside of the controller.
+	DriftDetectedCondition ConditionType = "DriftDetected"
+
+	// PausedCondition is true while spec.paused stops the helm operations. The observed generation
+	// is not updated until the vcluster is unpaused and deployed.
+	PausedCondition ConditionType = "Paused"
 )
 
 // ConditionSeverity expresses the severity of a Condition Type failing.
@@ -0,0 +1,7 @@
+package v1alpha1
+
+// Hub marks v1alpha1 as the storage version the other versions convert to and from
+func (*VCluster) Hub() {}
+
+// Hub marks v1alpha1 as the storage version the other versions convert to and from
+func (*VClusterList) Hub() {}
@@ -17,6 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -31,10 +34,82 @@ type VClusterSpec struct {
 	// +optional
 	ControlPlaneEndpoint clusterv1beta1.APIEndpoint `json:"controlPlaneEndpoint"`
 
+	// KubeconfigServerOverride is used verbatim as server of the kubeconfig Secret, e.g. when the
+	// control plane is exposed through an ingress. The control plane endpoint is still discovered.
+	// +optional
+	KubeconfigServerOverride string `json:"kubeconfigServerOverride,omitempty"`
+
+	// KubeconfigSecretName is the name of the Secret the kubeconfig is written to, defaults to
+	// <name>-kubeconfig as expected by Cluster API
+	// +optional
+	KubeconfigSecretName string `json:"kubeconfigSecretName,omitempty"`
+
 	// The helm release configuration for the virtual cluster. This is optional, but
 	// when filled, specified chart will be deployed.
 	// +optional
 	HelmRelease *VirtualClusterHelmRelease `json:"helmRelease,omitempty"`
+
+	// NetworkPolicy configures the network policies that isolate the virtual cluster
+	// namespace. When enabled, the controller creates and manages the policies.
+	// +optional
+	NetworkPolicy *VirtualClusterNetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// CAConfigMap configures publishing the virtual cluster CA certificate into a ConfigMap
+	// +optional
+	CAConfigMap *VirtualClusterCAConfigMap `json:"caConfigMap,omitempty"`
+
+	// ReadyzPath is the path of the control plane readiness endpoint, defaults to /readyz
+	// +optional
+	ReadyzPath string `json:"readyzPath,omitempty"`
+
+	// StartupProbePath is the path of the control plane liveness endpoint, e.g. /livez. If set, it is
+	// checked before the readiness endpoint to tell a starting control plane from a crashed one.
+	// +optional
+	StartupProbePath string `json:"startupProbePath,omitempty"`
+
+	// Proxy configures the HTTP proxy used by the virtual cluster control plane
+	// +optional
+	Proxy *VirtualClusterProxy `json:"proxy,omitempty"`
+
+	// IgnoreReadyzBody only checks the status code of the readiness endpoint
+	// instead of also expecting the body to be "ok"
+	// +optional
+	IgnoreReadyzBody bool `json:"ignoreReadyzBody,omitempty"`
+
+	// TopologySpread spreads the control plane replicas across failure domains
+	// +optional
+	TopologySpread *VirtualClusterTopologySpread `json:"topologySpread,omitempty"`
+
+	// LimitRange configures default container resources in the virtual cluster namespace.
+	// When set, the controller creates and manages a LimitRange.
+	// +optional
+	LimitRange *VirtualClusterLimitRange `json:"limitRange,omitempty"`
+
+	// ValuesSnapshot stores the deployed helm values and chart coordinates in a Secret after
+	// every successful deploy, so the virtual cluster can be recreated from it. Values taken from
+	// valuesFrom Secrets are stored as REDACTED.
+	// +optional
+	ValuesSnapshot *VirtualClusterValuesSnapshot `json:"valuesSnapshot,omitempty"`
+
+	// AuditLog configures audit logging of the virtual cluster api server. It is supported
+	// for the k8s and k3s distros of the vcluster chart 0.20 and newer.
+	// +optional
+	AuditLog *VirtualClusterAuditLog `json:"auditLog,omitempty"`
+
+	// ServiceAccountName is the name of an existing service account the control plane pods
+	// use. A service account name in the helm values takes precedence.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Paused stops all helm operations for the virtual cluster, while the kubeconfig and the
+	// status are still synced. Deleting the virtual cluster still removes the helm release.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Storage configures the persistent volume of the control plane. Storage values set in the
+	// helm values take precedence.
+	// +optional
+	Storage *VirtualClusterStorage `json:"storage,omitempty"`
 }
 
 // VClusterStatus defines the observed state of VCluster
@@ -70,6 +145,69 @@ type VClusterStatus struct {
 	// ObservedGeneration is the latest generation observed by the controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReconcileTime is the time of the last successful reconcile
+	// +optional
+	LastReconcileTime metav1.Time `json:"lastReconcileTime,omitempty"`
+
+	// LastHelmDeployTime is the time of the last successful helm install or upgrade
+	// +optional
+	LastHelmDeployTime metav1.Time `json:"lastHelmDeployTime,omitempty"`
+
+	// KubernetesVersion is the version of the Kubernetes API server running in the virtual cluster
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// ResolvedChartVersion is the chart version the configured version channel resolved to
+	// +optional
+	ResolvedChartVersion string `json:"resolvedChartVersion,omitempty"`
+
+	// ValuesFromHash is the hash of the referenced values sources of the last deploy, a change of
+	// the referenced Secrets or ConfigMaps redeploys the virtual cluster
+	// +optional
+	ValuesFromHash string `json:"valuesFromHash,omitempty"`
+
+	// InstalledChartVersion is the chart version of the deployed helm release
+	// +optional
+	InstalledChartVersion string `json:"installedChartVersion,omitempty"`
+
+	// InstalledAppVersion is the app version of the chart of the deployed helm release
+	// +optional
+	InstalledAppVersion string `json:"installedAppVersion,omitempty"`
+
+	// DiscoveredControlPlaneHost is the control plane host that was discovered from the
+	// vcluster service and written into the spec
+	// +optional
+	DiscoveredControlPlaneHost string `json:"discoveredControlPlaneHost,omitempty"`
+
+	// ReadyzHistory holds the results of the most recent control plane readiness checks, oldest first
+	// +optional
+	ReadyzHistory []VirtualClusterReadyzProbe `json:"readyzHistory,omitempty"`
+
+	// FailedHelmDeleteAttempts counts the failed helm deletes while the virtual cluster is deleted
+	// +optional
+	FailedHelmDeleteAttempts int32 `json:"failedHelmDeleteAttempts,omitempty"`
+
+	// PublishedCAConfigMap is the namespace/name of the ConfigMap the CA certificate was published to,
+	// it is removed once publishing is disabled, the ConfigMap is renamed or the VCluster is deleted
+	// +optional
+	PublishedCAConfigMap string `json:"publishedCAConfigMap,omitempty"`
+}
+
+type VirtualClusterReadyzProbe struct {
+	// the time the readiness check was done
+	Time metav1.Time `json:"time"`
+
+	// Ready defines if the control plane was ready
+	Ready bool `json:"ready"`
+
+	// the time the readiness check took
+	// +optional
+	Latency metav1.Duration `json:"latency,omitempty"`
+
+	// the error of the readiness check if the control plane was unreachable
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 // GetConditions returns the set of conditions for this object.
@@ -87,11 +225,58 @@ type VirtualClusterHelmRelease struct {
 	// +optional
 	Chart VirtualClusterHelmChart `json:"chart,omitempty"`
 
+	// ChartFrom references a Secret or ConfigMap in the namespace of the VCluster that holds the
+	// chart tgz gzipped and base64 encoded, e.g. for air-gapped environments. It is installed instead
+	// of the repository chart, the chart name and version still identify the chart.
+	// +optional
+	ChartFrom *VirtualClusterChartSource `json:"chartFrom,omitempty"`
+
+	// ValuesFrom references Secrets or ConfigMaps in the namespace of the VCluster that hold
+	// helm values. They are merged in order and the inline values take precedence over them.
+	// +optional
+	ValuesFrom []VirtualClusterValuesSource `json:"valuesFrom,omitempty"`
+
+	// ValuesMergeStrategies defines how lists of the valuesFrom sources and the inline values are
+	// merged, keyed by their dot separated path, e.g. sync.toHost.ingresses.patches. Lists are
+	// replaced by default, with append the items of later sources are appended to the list.
+	// +optional
+	ValuesMergeStrategies map[string]ValuesMergeStrategy `json:"valuesMergeStrategies,omitempty"`
+
 	// the values for the given chart
 	// +optional
 	Values string `json:"values,omitempty"`
 }
 
+// ValuesMergeStrategy defines how a list in the helm values is merged with an existing list
+// +kubebuilder:validation:Enum=replace;append
+type ValuesMergeStrategy string
+
+type VirtualClusterChartSource struct {
+	// Kind of the referenced object, either Secret or ConfigMap
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	Kind string `json:"kind"`
+
+	// Name of the referenced object
+	Name string `json:"name"`
+
+	// Key of the chart in the referenced object, defaults to chart
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+type VirtualClusterValuesSource struct {
+	// Kind of the referenced object, either Secret or ConfigMap
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	Kind string `json:"kind"`
+
+	// Name of the referenced object
+	Name string `json:"name"`
+
+	// Key of the values in the referenced object, defaults to values.yaml
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
 type VirtualClusterHelmChart struct {
 	// the name of the helm chart
 	// +optional
@@ -101,11 +286,152 @@ type VirtualClusterHelmChart struct {
 	// +optional
 	Repo string `json:"repo,omitempty"`
 
-	// the version of the helm chart to use
+	// RepoSecretRef references a Secret in the namespace of the VCluster with the username
+	// and password keys to authenticate against the repo and an optional ca.crt to verify it
+	// +optional
+	RepoSecretRef *corev1.LocalObjectReference `json:"repoSecretRef,omitempty"`
+
+	// the version of the helm chart to use, it can also reference a version
+	// channel such as @stable, which is resolved by the controller
 	// +optional
 	Version string `json:"version,omitempty"`
 }
 
+type VirtualClusterNetworkPolicy struct {
+	// Enabled defines if the controller should create network policies in the
+	// virtual cluster namespace
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Policies overrides the default set of network policies (default-deny plus
+	// allow rules for the control plane, the namespace and DNS, and for egress outside
+	// of the host pod network if the pod CIDR can be discovered)
+	// +optional
+	Policies []VirtualClusterNetworkPolicyTemplate `json:"policies,omitempty"`
+}
+
+type VirtualClusterNetworkPolicyTemplate struct {
+	// the name of the network policy, it will be prefixed with the virtual cluster name
+	Name string `json:"name"`
+
+	// the spec of the network policy
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Spec networkingv1.NetworkPolicySpec `json:"spec,omitempty"`
+}
+
+type VirtualClusterCAConfigMap struct {
+	// Enabled defines if the CA certificate should be published
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// the name of the ConfigMap, defaults to <vcluster name>-ca
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// the namespace of the ConfigMap, defaults to the VCluster namespace. Other namespaces must be
+	// allowed with the --allowed-ca-config-map-namespaces flag of the controller
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// the key of the CA certificate in the vcluster certs secret, defaults to ca.crt with a
+	// fallback to tls.crt
+	// +optional
+	SecretKey string `json:"secretKey,omitempty"`
+}
+
+type VirtualClusterProxy struct {
+	// the proxy url used for HTTP requests, e.g. http://proxy.example.com:3128
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// the proxy url used for HTTPS requests, e.g. http://proxy.example.com:3128
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// the hosts, domains and CIDRs that should not use the proxy
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+type VirtualClusterTopologySpread struct {
+	// ZoneSpread spreads highly available control plane replicas evenly across zones.
+	// It has no effect for a single replica.
+	// +optional
+	ZoneSpread bool `json:"zoneSpread,omitempty"`
+
+	// the topology spread constraints that are added to the control plane pods
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Constraints []corev1.TopologySpreadConstraint `json:"constraints,omitempty"`
+}
+
+type VirtualClusterLimitRange struct {
+	// the default resource limits of containers
+	// +optional
+	Default corev1.ResourceList `json:"default,omitempty"`
+
+	// the default resource requests of containers
+	// +optional
+	DefaultRequest corev1.ResourceList `json:"defaultRequest,omitempty"`
+
+	// the maximum resource limits of containers
+	// +optional
+	Max corev1.ResourceList `json:"max,omitempty"`
+}
+
+type VirtualClusterStorage struct {
+	// the name of the StorageClass of the persistent volume, defaults to the default StorageClass
+	// +optional
+	ClassName string `json:"className,omitempty"`
+
+	// the size of the persistent volume, defaults to the size of the chart
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+}
+
+type VirtualClusterValuesSnapshot struct {
+	// Enabled defines if the values snapshot should be stored
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// the name of the Secret in the VCluster namespace, defaults to <vcluster name>-values-snapshot.
+	// The Secret is not removed together with the VCluster. An existing Secret is only overwritten
+	// if it is a values snapshot of the VCluster.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+type VirtualClusterAuditLog struct {
+	// Enabled defines if audit logging should be enabled
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// the audit policy (audit.k8s.io/v1 Policy) as yaml
+	// +optional
+	Policy string `json:"policy,omitempty"`
+
+	// the path of the audit log inside the control plane container, defaults to "-" which
+	// writes the audit log to stdout
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// the maximum number of days to retain old audit log files
+	// +optional
+	MaxAge int32 `json:"maxAge,omitempty"`
+
+	// the maximum number of audit log files to retain
+	// +optional
+	MaxBackups int32 `json:"maxBackups,omitempty"`
+
+	// the maximum size in megabytes of an audit log file before it gets rotated
+	// +optional
+	MaxSize int32 `json:"maxSize,omitempty"`
+}
+
 // VirtualClusterPhase describes the phase of a virtual cluster
 type VirtualClusterPhase string
 
@@ -119,6 +445,11 @@ const (
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.kubernetesVersion"
+//+kubebuilder:printcolumn:name="Chart",type="string",JSONPath=".status.installedChartVersion"
+//+kubebuilder:printcolumn:name="Reconciled",type="date",JSONPath=".status.lastReconcileTime"
+//+kubebuilder:printcolumn:name="Deployed",type="date",JSONPath=".status.lastHelmDeployTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // VCluster is the Schema for the vclusters API
 type VCluster struct {
@@ -5,6 +5,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -111,8 +112,48 @@ func (in *VClusterSpec) DeepCopyInto(out *VClusterSpec) {
 	if in.HelmRelease != nil {
 		in, out := &in.HelmRelease, &out.HelmRelease
 		*out = new(VirtualClusterHelmRelease)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(VirtualClusterNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CAConfigMap != nil {
+		in, out := &in.CAConfigMap, &out.CAConfigMap
+		*out = new(VirtualClusterCAConfigMap)
 		**out = **in
 	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(VirtualClusterProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpread != nil {
+		in, out := &in.TopologySpread, &out.TopologySpread
+		*out = new(VirtualClusterTopologySpread)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(VirtualClusterLimitRange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ValuesSnapshot != nil {
+		in, out := &in.ValuesSnapshot, &out.ValuesSnapshot
+		*out = new(VirtualClusterValuesSnapshot)
+		**out = **in
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(VirtualClusterAuditLog)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(VirtualClusterStorage)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VClusterSpec.
@@ -135,6 +176,15 @@ func (in *VClusterStatus) DeepCopyInto(out *VClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	in.LastReconcileTime.DeepCopyInto(&out.LastReconcileTime)
+	in.LastHelmDeployTime.DeepCopyInto(&out.LastHelmDeployTime)
+	if in.ReadyzHistory != nil {
+		in, out := &in.ReadyzHistory, &out.ReadyzHistory
+		*out = make([]VirtualClusterReadyzProbe, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VClusterStatus.
@@ -147,9 +197,59 @@ func (in *VClusterStatus) DeepCopy() *VClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterAuditLog) DeepCopyInto(out *VirtualClusterAuditLog) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterAuditLog.
+func (in *VirtualClusterAuditLog) DeepCopy() *VirtualClusterAuditLog {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterAuditLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterCAConfigMap) DeepCopyInto(out *VirtualClusterCAConfigMap) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterCAConfigMap.
+func (in *VirtualClusterCAConfigMap) DeepCopy() *VirtualClusterCAConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterCAConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterChartSource) DeepCopyInto(out *VirtualClusterChartSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterChartSource.
+func (in *VirtualClusterChartSource) DeepCopy() *VirtualClusterChartSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterChartSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualClusterHelmChart) DeepCopyInto(out *VirtualClusterHelmChart) {
 	*out = *in
+	if in.RepoSecretRef != nil {
+		in, out := &in.RepoSecretRef, &out.RepoSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterHelmChart.
@@ -165,7 +265,24 @@ func (in *VirtualClusterHelmChart) DeepCopy() *VirtualClusterHelmChart {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualClusterHelmRelease) DeepCopyInto(out *VirtualClusterHelmRelease) {
 	*out = *in
-	out.Chart = in.Chart
+	in.Chart.DeepCopyInto(&out.Chart)
+	if in.ChartFrom != nil {
+		in, out := &in.ChartFrom, &out.ChartFrom
+		*out = new(VirtualClusterChartSource)
+		**out = **in
+	}
+	if in.ValuesFrom != nil {
+		in, out := &in.ValuesFrom, &out.ValuesFrom
+		*out = make([]VirtualClusterValuesSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValuesMergeStrategies != nil {
+		in, out := &in.ValuesMergeStrategies, &out.ValuesMergeStrategies
+		*out = make(map[string]ValuesMergeStrategy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterHelmRelease.
@@ -177,3 +294,186 @@ func (in *VirtualClusterHelmRelease) DeepCopy() *VirtualClusterHelmRelease {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterLimitRange) DeepCopyInto(out *VirtualClusterLimitRange) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.DefaultRequest != nil {
+		in, out := &in.DefaultRequest, &out.DefaultRequest
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterLimitRange.
+func (in *VirtualClusterLimitRange) DeepCopy() *VirtualClusterLimitRange {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterLimitRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterNetworkPolicy) DeepCopyInto(out *VirtualClusterNetworkPolicy) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]VirtualClusterNetworkPolicyTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterNetworkPolicy.
+func (in *VirtualClusterNetworkPolicy) DeepCopy() *VirtualClusterNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterNetworkPolicyTemplate) DeepCopyInto(out *VirtualClusterNetworkPolicyTemplate) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterNetworkPolicyTemplate.
+func (in *VirtualClusterNetworkPolicyTemplate) DeepCopy() *VirtualClusterNetworkPolicyTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterNetworkPolicyTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterProxy) DeepCopyInto(out *VirtualClusterProxy) {
+	*out = *in
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterProxy.
+func (in *VirtualClusterProxy) DeepCopy() *VirtualClusterProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterReadyzProbe) DeepCopyInto(out *VirtualClusterReadyzProbe) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.Latency = in.Latency
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterReadyzProbe.
+func (in *VirtualClusterReadyzProbe) DeepCopy() *VirtualClusterReadyzProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterReadyzProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterStorage) DeepCopyInto(out *VirtualClusterStorage) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterStorage.
+func (in *VirtualClusterStorage) DeepCopy() *VirtualClusterStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterTopologySpread) DeepCopyInto(out *VirtualClusterTopologySpread) {
+	*out = *in
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterTopologySpread.
+func (in *VirtualClusterTopologySpread) DeepCopy() *VirtualClusterTopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterTopologySpread)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterValuesSnapshot) DeepCopyInto(out *VirtualClusterValuesSnapshot) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterValuesSnapshot.
+func (in *VirtualClusterValuesSnapshot) DeepCopy() *VirtualClusterValuesSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterValuesSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualClusterValuesSource) DeepCopyInto(out *VirtualClusterValuesSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualClusterValuesSource.
+func (in *VirtualClusterValuesSource) DeepCopy() *VirtualClusterValuesSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualClusterValuesSource)
+	in.DeepCopyInto(out)
+	return out
+}
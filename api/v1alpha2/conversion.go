@@ -0,0 +1,152 @@
+package v1alpha2
+
+import (
+	"encoding/json"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// TemplateAnnotation keeps the template on the v1alpha1 hub if v1alpha1 can't represent it, e.g. an
+// empty template or a service account without a name
+const TemplateAnnotation = "v1alpha2.infrastructure.cluster.x-k8s.io/template"
+
+// ConvertTo converts the VCluster to the v1alpha1 hub version
+func (src *VCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.VCluster)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = v1alpha1.VClusterSpec{
+		ControlPlaneEndpoint:     src.Spec.ControlPlaneEndpoint,
+		KubeconfigServerOverride: src.Spec.KubeconfigServerOverride,
+		KubeconfigSecretName:     src.Spec.KubeconfigSecretName,
+		HelmRelease:              src.Spec.HelmRelease.DeepCopy(),
+		NetworkPolicy:            src.Spec.NetworkPolicy.DeepCopy(),
+		CAConfigMap:              src.Spec.CAConfigMap.DeepCopy(),
+		ReadyzPath:               src.Spec.ReadyzPath,
+		StartupProbePath:         src.Spec.StartupProbePath,
+		Proxy:                    src.Spec.Proxy.DeepCopy(),
+		IgnoreReadyzBody:         src.Spec.IgnoreReadyzBody,
+		TopologySpread:           src.Spec.TopologySpread.DeepCopy(),
+		LimitRange:               src.Spec.LimitRange.DeepCopy(),
+		ValuesSnapshot:           src.Spec.ValuesSnapshot.DeepCopy(),
+		AuditLog:                 src.Spec.AuditLog.DeepCopy(),
+		Paused:                   src.Spec.Paused,
+	}
+	dst.Spec.ServiceAccountName, dst.Spec.Storage = templateToHub(src.Spec.Template)
+	delete(dst.Annotations, TemplateAnnotation)
+	if !apiequality.Semantic.DeepEqual(src.Spec.Template, templateFromHub(dst.Spec.ServiceAccountName, dst.Spec.Storage)) {
+		data, err := json.Marshal(src.Spec.Template)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[TemplateAnnotation] = string(data)
+	}
+	dst.Status = *src.Status.DeepCopy()
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to the VCluster. The template is only set if
+// one of its fields is set, unless the hub kept the template in the TemplateAnnotation and its
+// fields weren't changed since.
+func (dst *VCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.VCluster)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = VClusterSpec{
+		ControlPlaneEndpoint:     src.Spec.ControlPlaneEndpoint,
+		KubeconfigServerOverride: src.Spec.KubeconfigServerOverride,
+		KubeconfigSecretName:     src.Spec.KubeconfigSecretName,
+		HelmRelease:              src.Spec.HelmRelease.DeepCopy(),
+		NetworkPolicy:            src.Spec.NetworkPolicy.DeepCopy(),
+		CAConfigMap:              src.Spec.CAConfigMap.DeepCopy(),
+		ReadyzPath:               src.Spec.ReadyzPath,
+		StartupProbePath:         src.Spec.StartupProbePath,
+		Proxy:                    src.Spec.Proxy.DeepCopy(),
+		IgnoreReadyzBody:         src.Spec.IgnoreReadyzBody,
+		TopologySpread:           src.Spec.TopologySpread.DeepCopy(),
+		LimitRange:               src.Spec.LimitRange.DeepCopy(),
+		ValuesSnapshot:           src.Spec.ValuesSnapshot.DeepCopy(),
+		AuditLog:                 src.Spec.AuditLog.DeepCopy(),
+		Paused:                   src.Spec.Paused,
+	}
+	dst.Spec.Template = templateFromHub(src.Spec.ServiceAccountName, src.Spec.Storage)
+	if data, ok := dst.Annotations[TemplateAnnotation]; ok {
+		delete(dst.Annotations, TemplateAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+
+		template := &VClusterTemplate{}
+		if json.Unmarshal([]byte(data), &template) == nil {
+			serviceAccountName, storage := templateToHub(template)
+			if serviceAccountName == src.Spec.ServiceAccountName && apiequality.Semantic.DeepEqual(storage, src.Spec.Storage) {
+				dst.Spec.Template = template
+			}
+		}
+	}
+	dst.Status = *src.Status.DeepCopy()
+	return nil
+}
+
+// templateToHub returns the v1alpha1 fields of the template
+func templateToHub(template *VClusterTemplate) (string, *v1alpha1.VirtualClusterStorage) {
+	if template == nil {
+		return "", nil
+	}
+
+	serviceAccountName := ""
+	if template.ServiceAccount != nil {
+		serviceAccountName = template.ServiceAccount.Name
+	}
+	return serviceAccountName, template.Storage.DeepCopy()
+}
+
+// templateFromHub returns the template of the v1alpha1 fields, nil if none of them is set
+func templateFromHub(serviceAccountName string, storage *v1alpha1.VirtualClusterStorage) *VClusterTemplate {
+	if serviceAccountName == "" && storage == nil {
+		return nil
+	}
+
+	template := &VClusterTemplate{
+		Storage: storage.DeepCopy(),
+	}
+	if serviceAccountName != "" {
+		template.ServiceAccount = &VClusterServiceAccount{
+			Name: serviceAccountName,
+		}
+	}
+	return template
+}
+
+// ConvertTo converts the VClusterList to the v1alpha1 hub version
+func (src *VClusterList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.VClusterList)
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]v1alpha1.VCluster, len(src.Items))
+	for i := range src.Items {
+		err := src.Items[i].ConvertTo(&dst.Items[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to the VClusterList
+func (dst *VClusterList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.VClusterList)
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]VCluster, len(src.Items))
+	for i := range src.Items {
+		err := dst.Items[i].ConvertFrom(&src.Items[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
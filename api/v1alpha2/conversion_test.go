@@ -0,0 +1,133 @@
+package v1alpha2
+
+import (
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/stretchr/testify/assert"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+const fuzzIterations = 500
+
+// newFuzzer fills all fields, including the ones gofuzz can't set on its own. The type meta is
+// left empty, as it is set by the conversion webhook.
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(seed).NilChance(0.3).NumElements(0, 3).Funcs(
+		func(*metav1.TypeMeta, fuzz.Continue) {},
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = *resource.NewQuantity(c.Int63n(1<<40), resource.BinarySI)
+		},
+		func(t *metav1.Time, c fuzz.Continue) {
+			*t = metav1.Unix(c.Int63n(1<<32), 0)
+		},
+	)
+}
+
+func TestFuzzyConversionHubSpokeHub(t *testing.T) {
+	f := newFuzzer(1)
+	for i := 0; i < fuzzIterations; i++ {
+		hub := &v1alpha1.VCluster{}
+		f.Fuzz(hub)
+
+		spoke := &VCluster{}
+		assert.NoError(t, spoke.ConvertFrom(hub.DeepCopy()))
+		converted := &v1alpha1.VCluster{}
+		assert.NoError(t, spoke.ConvertTo(converted))
+		if !apiequality.Semantic.DeepEqual(hub, converted) {
+			t.Fatalf("v1alpha1 -> v1alpha2 -> v1alpha1 lost data: %s", diff.ObjectReflectDiff(hub, converted))
+		}
+	}
+}
+
+func TestFuzzyConversionSpokeHubSpoke(t *testing.T) {
+	f := newFuzzer(2)
+	for i := 0; i < fuzzIterations; i++ {
+		spoke := &VCluster{}
+		f.Fuzz(spoke)
+
+		hub := &v1alpha1.VCluster{}
+		assert.NoError(t, spoke.DeepCopy().ConvertTo(hub))
+		converted := &VCluster{}
+		assert.NoError(t, converted.ConvertFrom(hub))
+		if !apiequality.Semantic.DeepEqual(spoke, converted) {
+			t.Fatalf("v1alpha2 -> v1alpha1 -> v1alpha2 lost data: %s", diff.ObjectReflectDiff(spoke, converted))
+		}
+	}
+}
+
+func TestConversionTemplate(t *testing.T) {
+	size := resource.MustParse("10Gi")
+	hub := &v1alpha1.VCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1alpha1.VClusterSpec{
+			ServiceAccountName: "vcluster",
+			Storage:            &v1alpha1.VirtualClusterStorage{ClassName: "fast", Size: &size},
+		},
+	}
+
+	spoke := &VCluster{}
+	assert.NoError(t, spoke.ConvertFrom(hub))
+	assert.Equal(t, &VClusterTemplate{
+		ServiceAccount: &VClusterServiceAccount{Name: "vcluster"},
+		Storage:        &v1alpha1.VirtualClusterStorage{ClassName: "fast", Size: &size},
+	}, spoke.Spec.Template)
+
+	spoke = &VCluster{}
+	assert.NoError(t, spoke.ConvertFrom(&v1alpha1.VCluster{}))
+	assert.Nil(t, spoke.Spec.Template)
+}
+
+func TestConversionList(t *testing.T) {
+	hubList := &v1alpha1.VClusterList{
+		Items: []v1alpha1.VCluster{
+			{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: v1alpha1.VClusterSpec{ServiceAccountName: "a"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		},
+	}
+
+	spokeList := &VClusterList{}
+	assert.NoError(t, spokeList.ConvertFrom(hubList))
+	if assert.Len(t, spokeList.Items, 2) {
+		assert.Equal(t, "a", spokeList.Items[0].Spec.Template.ServiceAccount.Name)
+		assert.Nil(t, spokeList.Items[1].Spec.Template)
+	}
+
+	converted := &v1alpha1.VClusterList{}
+	assert.NoError(t, spokeList.ConvertTo(converted))
+	assert.Equal(t, hubList.Items, converted.Items)
+}
+
+func TestConversionUnrepresentableTemplate(t *testing.T) {
+	for name, template := range map[string]*VClusterTemplate{
+		"empty template":                 {},
+		"service account without a name": {ServiceAccount: &VClusterServiceAccount{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			spoke := &VCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec:       VClusterSpec{Template: template},
+			}
+
+			hub := &v1alpha1.VCluster{}
+			assert.NoError(t, spoke.ConvertTo(hub))
+			assert.Contains(t, hub.Annotations, TemplateAnnotation)
+
+			converted := &VCluster{}
+			assert.NoError(t, converted.ConvertFrom(hub.DeepCopy()))
+			assert.Equal(t, spoke, converted)
+
+			// changes of the hub take precedence over the kept template
+			hub.Spec.ServiceAccountName = "vcluster"
+			converted = &VCluster{}
+			assert.NoError(t, converted.ConvertFrom(hub))
+			assert.Equal(t, &VClusterTemplate{ServiceAccount: &VClusterServiceAccount{Name: "vcluster"}}, converted.Spec.Template)
+			assert.NotContains(t, converted.Annotations, TemplateAnnotation)
+		})
+	}
+}
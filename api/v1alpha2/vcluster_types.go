@@ -0,0 +1,156 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// VClusterSpec defines the desired state of VCluster
+type VClusterSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
+	// +optional
+	ControlPlaneEndpoint clusterv1beta1.APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// KubeconfigServerOverride is used verbatim as server of the kubeconfig Secret, e.g. when the
+	// control plane is exposed through an ingress. The control plane endpoint is still discovered.
+	// +optional
+	KubeconfigServerOverride string `json:"kubeconfigServerOverride,omitempty"`
+
+	// KubeconfigSecretName is the name of the Secret the kubeconfig is written to, defaults to
+	// <name>-kubeconfig as expected by Cluster API
+	// +optional
+	KubeconfigSecretName string `json:"kubeconfigSecretName,omitempty"`
+
+	// The helm release configuration for the virtual cluster. This is optional, but
+	// when filled, specified chart will be deployed.
+	// +optional
+	HelmRelease *v1alpha1.VirtualClusterHelmRelease `json:"helmRelease,omitempty"`
+
+	// Template configures the control plane workload of the virtual cluster
+	// +optional
+	Template *VClusterTemplate `json:"template,omitempty"`
+
+	// NetworkPolicy configures the network policies that isolate the virtual cluster
+	// namespace. When enabled, the controller creates and manages the policies.
+	// +optional
+	NetworkPolicy *v1alpha1.VirtualClusterNetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// CAConfigMap configures publishing the virtual cluster CA certificate into a ConfigMap
+	// +optional
+	CAConfigMap *v1alpha1.VirtualClusterCAConfigMap `json:"caConfigMap,omitempty"`
+
+	// ReadyzPath is the path of the control plane readiness endpoint, defaults to /readyz
+	// +optional
+	ReadyzPath string `json:"readyzPath,omitempty"`
+
+	// StartupProbePath is the path of the control plane liveness endpoint, e.g. /livez. If set, it is
+	// checked before the readiness endpoint to tell a starting control plane from a crashed one.
+	// +optional
+	StartupProbePath string `json:"startupProbePath,omitempty"`
+
+	// Proxy configures the HTTP proxy used by the virtual cluster control plane
+	// +optional
+	Proxy *v1alpha1.VirtualClusterProxy `json:"proxy,omitempty"`
+
+	// IgnoreReadyzBody only checks the status code of the readiness endpoint
+	// instead of also expecting the body to be "ok"
+	// +optional
+	IgnoreReadyzBody bool `json:"ignoreReadyzBody,omitempty"`
+
+	// TopologySpread spreads the control plane replicas across failure domains
+	// +optional
+	TopologySpread *v1alpha1.VirtualClusterTopologySpread `json:"topologySpread,omitempty"`
+
+	// LimitRange configures default container resources in the virtual cluster namespace.
+	// When set, the controller creates and manages a LimitRange.
+	// +optional
+	LimitRange *v1alpha1.VirtualClusterLimitRange `json:"limitRange,omitempty"`
+
+	// ValuesSnapshot stores the deployed helm values and chart coordinates in a Secret after
+	// every successful deploy, so the virtual cluster can be recreated from it. Values taken from
+	// valuesFrom Secrets are stored as REDACTED.
+	// +optional
+	ValuesSnapshot *v1alpha1.VirtualClusterValuesSnapshot `json:"valuesSnapshot,omitempty"`
+
+	// AuditLog configures audit logging of the virtual cluster api server. It is supported
+	// for the k8s and k3s distros of the vcluster chart 0.20 and newer.
+	// +optional
+	AuditLog *v1alpha1.VirtualClusterAuditLog `json:"auditLog,omitempty"`
+
+	// Paused stops all helm operations for the virtual cluster, while the kubeconfig and the
+	// status are still synced. Deleting the virtual cluster still removes the helm release.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// VClusterTemplate groups the settings of the control plane pods that were top level fields of
+// the v1alpha1 spec. Settings in the helm values take precedence.
+type VClusterTemplate struct {
+	// ServiceAccount configures the service account of the control plane pods
+	// +optional
+	ServiceAccount *VClusterServiceAccount `json:"serviceAccount,omitempty"`
+
+	// Storage configures the persistent volume of the control plane
+	// +optional
+	Storage *v1alpha1.VirtualClusterStorage `json:"storage,omitempty"`
+}
+
+type VClusterServiceAccount struct {
+	// Name is the name of an existing service account the control plane pods use
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:unservedversion
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.kubernetesVersion"
+//+kubebuilder:printcolumn:name="Chart",type="string",JSONPath=".status.installedChartVersion"
+//+kubebuilder:printcolumn:name="Reconciled",type="date",JSONPath=".status.lastReconcileTime"
+//+kubebuilder:printcolumn:name="Deployed",type="date",JSONPath=".status.lastHelmDeployTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// VCluster is the Schema for the vclusters API. The version is scaffolding only and not served,
+// v1alpha1 stays the served and storage version.
+type VCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VClusterSpec            `json:"spec,omitempty"`
+	Status v1alpha1.VClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VClusterList contains a list of VCluster
+type VClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VCluster{}, &VClusterList{})
+}
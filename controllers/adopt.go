@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// HelmReleaseAdoptedReason is used for the event of an adopted helm release.
+	HelmReleaseAdoptedReason = "HelmReleaseAdopted"
+)
+
+// adoptHelmRelease marks an existing deployed helm release as deployed without upgrading it, if the
+// VCluster opted in via annotation, was never reconciled before, the release is of the chart and
+// version of the spec and the controller wouldn't set any helm values. It returns false if the
+// release should be installed or upgraded as usual.
+func (r *VClusterReconciler) adoptHelmRelease(ctx context.Context, vCluster *v1alpha1.VCluster, chartName, chartVersion, resolvedVersion, values string) (bool, error) {
+	if vCluster.Annotations[AdoptAnnotation] != "true" || r.HelmSecrets == nil || vCluster.Status.ObservedGeneration != 0 {
+		return false, nil
+	}
+
+	// the adopted release wouldn't have the values of the inline values, the values sources or the spec
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return false, err
+	} else if len(parsed) > 0 {
+		r.Log.Info("not adopting the helm release, as the vcluster sets helm values",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
+		return false, nil
+	}
+
+	release, err := r.HelmSecrets.Get(ctx, vCluster.Name, vCluster.Namespace)
+	if kerrors.IsNotFound(err) {
+		r.Log.Info("no helm release to adopt, installing the chart",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("get helm release to adopt: %w", err)
+	} else if release.Info == nil || release.Info.Status != "deployed" {
+		return false, nil
+	} else if release.Chart == nil || release.Chart.Metadata == nil || release.Chart.Metadata.Name != chartName || strings.TrimPrefix(release.Chart.Metadata.Version, "v") != strings.TrimPrefix(chartVersion, "v") {
+		r.Log.Info("not adopting the helm release, as it is of another chart than the spec",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"chart", chartName,
+			"version", chartVersion,
+		)
+		return false, nil
+	}
+
+	r.Log.Info("adopt existing helm release",
+		"namespace", vCluster.Namespace,
+		"name", vCluster.Name,
+		"revision", release.Version,
+	)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(vCluster, corev1.EventTypeNormal, HelmReleaseAdoptedReason, "adopted helm release revision %d", release.Version)
+	}
+
+	vCluster.Status.ResolvedChartVersion = resolvedVersion
+	conditions.MarkTrue(vCluster, v1alpha1.HelmChartDeployedCondition)
+	r.recordInstalledRelease(ctx, vCluster)
+	return true, nil
+}
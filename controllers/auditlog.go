@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// AuditPolicyKey is the key of the audit policy in the audit policy ConfigMap
+	AuditPolicyKey = "audit-policy.yaml"
+
+	auditPolicyVolumeName = "audit-policy"
+	auditPolicyMountPath  = "/etc/vcluster/audit"
+)
+
+func auditPolicyConfigMapName(vCluster *v1alpha1.VCluster) string {
+	return vCluster.Name + "-audit-policy"
+}
+
+func auditLogEnabled(vCluster *v1alpha1.VCluster) bool {
+	return vCluster.Spec.AuditLog != nil && vCluster.Spec.AuditLog.Enabled
+}
+
+// auditLogSupported returns true if the chart has the distro values audit logging is configured with,
+// which the vcluster chart has since 0.20
+func auditLogSupported(chartName, chartVersion string) bool {
+	if path.Base(chartName) != "vcluster" {
+		return false
+	}
+
+	version, err := semver.NewVersion(chartVersion)
+	return err != nil || !version.LessThan(semver.MustParse("0.20.0-alpha.0"))
+}
+
+// mergeAuditLogValues adds the audit log flags of the api server and the audit policy volume to the helm values.
+// The flags are added depending on the distro configured in the values.
+func mergeAuditLogValues(vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) (string, error) {
+	if !auditLogEnabled(vCluster) {
+		return values, nil
+	} else if !auditLogSupported(chartName, chartVersion) {
+		return "", fmt.Errorf("audit logging is not supported for chart %s %s, it requires the vcluster chart 0.20 or newer", chartName, chartVersion)
+	}
+
+	auditLog := vCluster.Spec.AuditLog
+	err := validateAuditPolicy(auditLog.Policy)
+	if err != nil {
+		return "", fmt.Errorf("invalid audit policy: %w", err)
+	}
+
+	logPath := auditLog.Path
+	if logPath == "" {
+		logPath = "-"
+	}
+	args := []string{
+		"audit-policy-file=" + path.Join(auditPolicyMountPath, AuditPolicyKey),
+		"audit-log-path=" + logPath,
+	}
+	for _, flag := range []struct {
+		name  string
+		value int32
+	}{
+		{name: "audit-log-maxage", value: auditLog.MaxAge},
+		{name: "audit-log-maxbackup", value: auditLog.MaxBackups},
+		{name: "audit-log-maxsize", value: auditLog.MaxSize},
+	} {
+		if flag.value < 0 {
+			return "", fmt.Errorf("%s must not be negative", flag.name)
+		} else if flag.value > 0 {
+			args = append(args, flag.name+"="+strconv.Itoa(int(flag.value)))
+		}
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	extraArgs := []interface{}{}
+	var extraArgsPath []string
+	switch distro := valuesDistro(parsed); distro {
+	case "k8s":
+		extraArgsPath = []string{"controlPlane", "distro", "k8s", "apiServer", "extraArgs"}
+		for _, arg := range args {
+			extraArgs = append(extraArgs, "--"+arg)
+		}
+	case "k3s":
+		extraArgsPath = []string{"controlPlane", "distro", "k3s", "extraArgs"}
+		for _, arg := range args {
+			extraArgs = append(extraArgs, "--kube-apiserver-arg="+arg)
+		}
+	default:
+		return "", fmt.Errorf("audit logging is not supported for distro %s", distro)
+	}
+
+	values, err = vclustervalues.Append(values, extraArgs, extraArgsPath...)
+	if err != nil {
+		return "", err
+	}
+	values, err = vclustervalues.Append(values, []interface{}{
+		map[string]interface{}{
+			"name": auditPolicyVolumeName,
+			"configMap": map[string]interface{}{
+				"name": auditPolicyConfigMapName(vCluster),
+			},
+		},
+	}, "controlPlane", "statefulSet", "persistence", "addVolumes")
+	if err != nil {
+		return "", err
+	}
+
+	return vclustervalues.Append(values, []interface{}{
+		map[string]interface{}{
+			"name":      auditPolicyVolumeName,
+			"mountPath": auditPolicyMountPath,
+			"readOnly":  true,
+		},
+	}, "controlPlane", "statefulSet", "persistence", "addVolumeMounts")
+}
+
+// valuesDistro returns the distro that is enabled in the helm values, the vcluster chart uses k8s if none is enabled
+func valuesDistro(values map[string]interface{}) string {
+	for _, distro := range []string{"k3s", "k0s", "k8s"} {
+		if enabled, _ := vclustervalues.Lookup(values, "controlPlane", "distro", distro, "enabled").(bool); enabled {
+			return distro
+		}
+	}
+
+	return "k8s"
+}
+
+// validateAuditPolicy makes sure the policy is an audit.k8s.io/v1 Policy with valid rule levels,
+// as the api server would otherwise fail to start
+func validateAuditPolicy(policy string) error {
+	parsed := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Rules      []struct {
+			Level string `json:"level"`
+		} `json:"rules"`
+	}{}
+	err := yaml.Unmarshal([]byte(policy), &parsed)
+	if err != nil {
+		return err
+	}
+	if parsed.APIVersion != "audit.k8s.io/v1" || parsed.Kind != "Policy" {
+		return fmt.Errorf("expected an audit.k8s.io/v1 Policy, got %s %s", parsed.APIVersion, parsed.Kind)
+	}
+	if len(parsed.Rules) == 0 {
+		return fmt.Errorf("the policy has no rules")
+	}
+
+	levels := []string{"None", "Metadata", "Request", "RequestResponse"}
+	for i, rule := range parsed.Rules {
+		if !slices.Contains(levels, rule.Level) {
+			return fmt.Errorf("rule %d has invalid level %q, must be one of %s", i, rule.Level, strings.Join(levels, ", "))
+		}
+	}
+
+	return nil
+}
+
+// reconcileAuditPolicy creates the ConfigMap holding the audit policy that is mounted into the control plane
+func (r *VClusterReconciler) reconcileAuditPolicy(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      auditPolicyConfigMapName(vCluster),
+			Namespace: vCluster.Namespace,
+		},
+	}
+	if !auditLogEnabled(vCluster) {
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+
+			return fmt.Errorf("get audit policy config map: %w", err)
+		} else if !metav1.IsControlledBy(configMap, vCluster) {
+			return nil
+		}
+
+		err = r.Client.Delete(ctx, configMap)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("delete audit policy config map: %w", err)
+		}
+
+		return nil
+	}
+
+	_, err := controllerutil.CreateOrPatch(ctx, r.Client, configMap, func() error {
+		configMap.Data = map[string]string{
+			AuditPolicyKey: vCluster.Spec.AuditLog.Policy,
+		}
+		return controllerutil.SetControllerReference(vCluster, configMap, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("create audit policy config map: %w", err)
+	}
+
+	return nil
+}
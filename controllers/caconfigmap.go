@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+const (
+	// CACertDataName is the key of the CA certificate in the vcluster certs secret and the published ConfigMap.
+	CACertDataName = "ca.crt"
+
+	// TLSCertDataName is the key of the certificate in secrets of type kubernetes.io/tls, it is used
+	// as fallback for the CA certificate.
+	TLSCertDataName = "tls.crt"
+
+	// CAConfigMapOwnerAnnotation is set on published CA ConfigMaps and holds the namespace/name of the
+	// VCluster. Existing ConfigMaps without it are neither overwritten nor deleted.
+	CAConfigMapOwnerAnnotation = "vcluster.loft.sh/ca-config-map-owner"
+)
+
+// caConfigMapName returns the namespaced name of the ConfigMap the CA is published to
+func caConfigMapName(vCluster *v1alpha1.VCluster) types.NamespacedName {
+	name := types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name + "-ca"}
+	if vCluster.Spec.CAConfigMap != nil {
+		if vCluster.Spec.CAConfigMap.Namespace != "" {
+			name.Namespace = vCluster.Spec.CAConfigMap.Namespace
+		}
+		if vCluster.Spec.CAConfigMap.Name != "" {
+			name.Name = vCluster.Spec.CAConfigMap.Name
+		}
+	}
+
+	return name
+}
+
+// checkCAConfigMapNamespace returns an error if the CA is published to a namespace other than the
+// one of the VCluster that is not in the allowed namespaces
+func checkCAConfigMapNamespace(vCluster *v1alpha1.VCluster, allowedNamespaces []string) error {
+	namespace := caConfigMapName(vCluster).Namespace
+	if namespace == vCluster.Namespace || slices.Contains(allowedNamespaces, namespace) {
+		return nil
+	}
+
+	if len(allowedNamespaces) == 0 {
+		return fmt.Errorf("the ca config map can not be published to namespace %s, only the namespace of the vcluster is allowed", namespace)
+	}
+	return fmt.Errorf("the ca config map can not be published to namespace %s, allowed namespaces are the namespace of the vcluster and: %s", namespace, strings.Join(allowedNamespaces, ", "))
+}
+
+func (r *VClusterReconciler) syncCAConfigMap(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	if vCluster.Spec.CAConfigMap == nil || !vCluster.Spec.CAConfigMap.Enabled {
+		return r.deletePublishedCAConfigMap(ctx, vCluster)
+	}
+
+	err := checkCAConfigMapNamespace(vCluster, r.AllowedCAConfigMapNamespaces)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: The secret name must be kept in sync with the certs secret created by the vcluster chart
+	certsSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name + "-certs"}, certsSecret)
+	if kerrors.IsNotFound(err) {
+		return fmt.Errorf("%w %s/%s-certs: %w", ErrCertsPending, vCluster.Namespace, vCluster.Name, err)
+	} else if err != nil {
+		return fmt.Errorf("get vcluster certs secret: %w", err)
+	}
+
+	caCert, err := caCertFromSecret(certsSecret, vCluster.Spec.CAConfigMap.SecretKey)
+	if err != nil {
+		return err
+	}
+
+	// remove the config map the ca was published to before it was renamed
+	name := caConfigMapName(vCluster)
+	if vCluster.Status.PublishedCAConfigMap != name.String() {
+		err = r.deletePublishedCAConfigMap(ctx, vCluster)
+		if err != nil {
+			return err
+		}
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrPatch(ctx, r.Client, configMap, func() error {
+		if configMap.ResourceVersion != "" && !ownsCAConfigMap(vCluster, configMap) {
+			return fmt.Errorf("config map %s exists and is not owned by the vcluster", name)
+		}
+
+		if configMap.Annotations == nil {
+			configMap.Annotations = map[string]string{}
+		}
+		configMap.Annotations[CAConfigMapOwnerAnnotation] = vCluster.Namespace + "/" + vCluster.Name
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[CACertDataName] = string(caCert)
+
+		// owner references are not allowed across namespaces, those config maps are removed on deletion instead
+		if configMap.Namespace == vCluster.Namespace {
+			return controllerutil.SetControllerReference(vCluster, configMap, r.Scheme)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("can not create a ca config map: %w", err)
+	}
+
+	vCluster.Status.PublishedCAConfigMap = name.String()
+	return nil
+}
+
+func (r *VClusterReconciler) deleteCAConfigMap(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	// the ca might have been published before the status recorded it
+	if vCluster.Status.PublishedCAConfigMap == "" && vCluster.Spec.CAConfigMap != nil && vCluster.Spec.CAConfigMap.Enabled {
+		vCluster.Status.PublishedCAConfigMap = caConfigMapName(vCluster).String()
+	}
+
+	return r.deletePublishedCAConfigMap(ctx, vCluster)
+}
+
+// deletePublishedCAConfigMap deletes the config map recorded in the status if it is owned by the
+// vcluster and clears the status
+func (r *VClusterReconciler) deletePublishedCAConfigMap(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	if vCluster.Status.PublishedCAConfigMap == "" {
+		return nil
+	}
+
+	namespace, name, _ := strings.Cut(vCluster.Status.PublishedCAConfigMap, "/")
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, configMap)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("get ca config map: %w", err)
+	} else if err == nil {
+		if !ownsCAConfigMap(vCluster, configMap) {
+			r.Log.Info("keep ca config map that is not owned by the vcluster",
+				"namespace", vCluster.Namespace,
+				"name", vCluster.Name,
+				"configMap", vCluster.Status.PublishedCAConfigMap,
+			)
+		} else {
+			err = r.Client.Delete(ctx, configMap, client.Preconditions{UID: &configMap.UID})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("delete ca config map: %w", err)
+			}
+		}
+	}
+
+	vCluster.Status.PublishedCAConfigMap = ""
+	return nil
+}
+
+// ownsCAConfigMap returns true if the config map was published by the vcluster, config maps in the
+// vcluster namespace might also only have the controller reference of older releases
+func ownsCAConfigMap(vCluster *v1alpha1.VCluster, configMap *corev1.ConfigMap) bool {
+	return configMap.Annotations[CAConfigMapOwnerAnnotation] == vCluster.Namespace+"/"+vCluster.Name || metav1.IsControlledBy(configMap, vCluster)
+}
+
+// caCertFromSecret returns the CA certificate of the certs secret. A configured key is used as is,
+// otherwise ca.crt is used with a fallback to tls.crt. The CA key is not needed.
+func caCertFromSecret(certsSecret *corev1.Secret, key string) ([]byte, error) {
+	keys := []string{CACertDataName, TLSCertDataName}
+	if key != "" {
+		keys = []string{key}
+	}
+
+	for _, k := range keys {
+		if caCert := certsSecret.Data[k]; len(caCert) > 0 {
+			return caCert, nil
+		}
+	}
+
+	return nil, fmt.Errorf("couldn't find %s in vcluster certs secret", strings.Join(keys, " or "))
+}
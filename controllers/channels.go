@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+const (
+	// ChartChannelPrefix marks a chart version as a channel, e.g. @stable
+	ChartChannelPrefix = "@"
+)
+
+// resolveChartChannel resolves a chart version channel (e.g. @stable) to the version configured
+// in the channels ConfigMap. It returns an empty string if the chart version is not a channel.
+func (r *VClusterReconciler) resolveChartChannel(ctx context.Context, vCluster *v1alpha1.VCluster) (string, error) {
+	if vCluster.Spec.HelmRelease == nil || !strings.HasPrefix(vCluster.Spec.HelmRelease.Chart.Version, ChartChannelPrefix) {
+		return "", nil
+	}
+
+	channel := strings.TrimPrefix(vCluster.Spec.HelmRelease.Chart.Version, ChartChannelPrefix)
+	if r.ChartChannelsConfigMap.Name == "" {
+		return "", fmt.Errorf("chart version channel %s is used, but no channels config map is configured", channel)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, r.ChartChannelsConfigMap, configMap)
+	if err != nil {
+		return "", fmt.Errorf("get chart channels config map: %w", err)
+	}
+
+	version := strings.TrimSpace(configMap.Data[channel])
+	if version == "" {
+		return "", fmt.Errorf("chart version channel %s is not defined in config map %s", channel, r.ChartChannelsConfigMap.String())
+	}
+
+	return version, nil
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+// ChartChecksumSuffix is appended to the path of a cached chart to get the file holding its sha256
+// checksum, in the format of sha256sum or only the hex digest
+const ChartChecksumSuffix = ".sha256"
+
+// cachedChartPath returns the path of the cached chart tgz or an empty string if the chart is not cached
+// or doesn't match its checksum, in which case the chart is installed from the repository.
+func (r *VClusterReconciler) cachedChartPath(vCluster *v1alpha1.VCluster, chartName, chartVersion string) string {
+	chartPath := "./" + chartName + "-" + chartVersion + ".tgz"
+	if r.ChartCacheDir != "" {
+		// create the cache directory, so it can be populated by others, e.g. a sidecar
+		err := os.MkdirAll(r.ChartCacheDir, 0o755)
+		if err != nil {
+			r.Log.Info("error creating chart cache directory",
+				"path", r.ChartCacheDir,
+				"err", err,
+			)
+			return ""
+		}
+
+		chartPath = filepath.Join(r.ChartCacheDir, chartName+"-"+chartVersion+".tgz")
+	}
+
+	_, err := os.Stat(chartPath)
+	if err != nil {
+		return ""
+	}
+
+	err = verifyChartChecksum(chartPath)
+	if err != nil {
+		r.Log.Info("ignoring cached chart",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"path", chartPath,
+			"err", err,
+		)
+		return ""
+	}
+
+	return chartPath
+}
+
+// verifyChartChecksum compares the sha256 of the chart with the checksum file next to it. Charts
+// without a checksum file are not verified.
+func verifyChartChecksum(chartPath string) error {
+	raw, err := os.ReadFile(chartPath + ChartChecksumSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read checksum: %w", err)
+	}
+
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file %s is empty", chartPath+ChartChecksumSuffix)
+	}
+
+	f, err := os.Open(chartPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return fmt.Errorf("hash chart: %w", err)
+	}
+
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if !strings.EqualFold(actual, fields[0]) {
+		return fmt.Errorf("checksum mismatch, expected %s but got %s", fields[0], actual)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/compress"
+)
+
+// DefaultChartFromKey is the key that is used if a chart source doesn't specify one
+const DefaultChartFromKey = "chart"
+
+// chartFromPath writes the chart of the referenced Secret or ConfigMap into a temp file and returns its
+// path and a function that removes it. An empty path is returned if the VCluster doesn't reference a chart.
+func (r *VClusterReconciler) chartFromPath(ctx context.Context, vCluster *v1alpha1.VCluster) (string, func(), error) {
+	if vCluster.Spec.HelmRelease == nil || vCluster.Spec.HelmRelease.ChartFrom == nil {
+		return "", func() {}, nil
+	}
+
+	source := vCluster.Spec.HelmRelease.ChartFrom
+	key := source.Key
+	if key == "" {
+		key = DefaultChartFromKey
+	}
+
+	data, err := r.getSourceData(ctx, vCluster.Namespace, "chart", source.Kind, source.Name, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	chart, err := compress.Uncompress(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("decompress chart of %s %s: %w", source.Kind, source.Name, err)
+	}
+
+	tempFile, err := os.CreateTemp("", "chart-*.tgz")
+	if err != nil {
+		return "", nil, fmt.Errorf("create chart file: %w", err)
+	}
+	cleanup := func() {
+		_ = os.Remove(tempFile.Name())
+	}
+
+	_, err = tempFile.WriteString(chart)
+	if err == nil {
+		err = tempFile.Close()
+	} else {
+		_ = tempFile.Close()
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("write chart file: %w", err)
+	}
+
+	return tempFile.Name(), cleanup, nil
+}
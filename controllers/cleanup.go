@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+// CleanupHook cleans up additional resources of a deleted VCluster, e.g. DNS records or external
+// load balancers. Hooks must be idempotent, as they are called again if a hook fails.
+type CleanupHook func(ctx context.Context, vCluster *v1alpha1.VCluster) error
+
+// finalizer returns the name of the finalizer that guards the cleanup of the VCluster
+func (r *VClusterReconciler) finalizer() string {
+	if r.Finalizer != "" {
+		return r.Finalizer
+	}
+
+	return CleanupFinalizer
+}
+
+// removeFinalizer removes the cleanup finalizer from the VCluster. The default finalizer is removed
+// as well, as VClusters created before the finalizer name was changed still carry it.
+func (r *VClusterReconciler) removeFinalizer(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	return RemoveFinalizer(ctx, r.Client, vCluster, r.finalizer(), CleanupFinalizer)
+}
+
+// runCleanupHooks calls the cleanup hooks in order and stops at the first error, so the deletion
+// is retried and the finalizer is only removed once all hooks succeeded.
+func (r *VClusterReconciler) runCleanupHooks(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	for i, hook := range r.CleanupHooks {
+		err := hook(ctx, vCluster)
+		if err != nil {
+			return fmt.Errorf("cleanup hook %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
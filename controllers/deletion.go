@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/patch"
+)
+
+const (
+	// DeletingHelmReleaseReason is used while the helm release of the vcluster is deleted.
+	DeletingHelmReleaseReason = "DeletingHelmRelease"
+
+	// WaitingForPodsReason is used while the pods of the vcluster are terminating.
+	WaitingForPodsReason = "WaitingForPods"
+
+	// DeletingPersistentVolumeClaimsReason is used while the persistent volume claims are deleted.
+	DeletingPersistentVolumeClaimsReason = "DeletingPersistentVolumeClaims"
+
+	// DeletingCAConfigMapReason is used while the published ca config map is deleted.
+	DeletingCAConfigMapReason = "DeletingCAConfigMap"
+
+	// RunningCleanupHooksReason is used while the additional cleanup hooks are called.
+	RunningCleanupHooksReason = "RunningCleanupHooks"
+
+	// HelmReleaseOrphanedReason is used when a vcluster is force deleted although its helm
+	// release couldn't be deleted.
+	HelmReleaseOrphanedReason = "HelmReleaseOrphaned"
+)
+
+// reconcileDelete cleans up the vcluster and removes the finalizer once done. The current cleanup
+// stage is reported in the CleanupSucceeded condition until then.
+func (r *VClusterReconciler) reconcileDelete(ctx context.Context, vCluster *v1alpha1.VCluster) (_ ctrl.Result, reterr error) {
+	// check if namespace is deleting
+	namespace := &corev1.Namespace{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: vCluster.Namespace}, namespace)
+	if err != nil {
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(vCluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the vcluster is gone once the finalizer is removed, so the conditions are only patched
+	// while the cleanup is in progress
+	done := false
+	defer func() {
+		if done {
+			return
+		}
+		if err := patchCluster(ctx, patchHelper, vCluster); err != nil {
+			reterr = utilerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	// nothing to clean up if the namespace is deleted anyways
+	if namespace.DeletionTimestamp != nil {
+		r.forgetDeleted(vCluster)
+		done = true
+		return ctrl.Result{}, r.removeFinalizer(ctx, vCluster)
+	}
+
+	conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, DeletingHelmReleaseReason, v1alpha1.ConditionSeverityInfo, "deleting helm release")
+	err = r.deleteHelmChart(ctx, vCluster.Namespace, vCluster.Name)
+	if err != nil {
+		vCluster.Status.FailedHelmDeleteAttempts++
+		if forceDelete(vCluster) {
+			r.Log.Info("force deleting vcluster, the helm release might be orphaned",
+				"namespace", vCluster.Namespace,
+				"name", vCluster.Name,
+				"attempts", vCluster.Status.FailedHelmDeleteAttempts,
+				"err", err,
+			)
+			if r.Recorder != nil {
+				r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, HelmReleaseOrphanedReason, "force deleted after %d failed helm deletes, the helm release might be orphaned: %v", vCluster.Status.FailedHelmDeleteAttempts, err)
+			}
+
+			r.forgetDeleted(vCluster)
+			done = true
+			return ctrl.Result{}, r.removeFinalizer(ctx, vCluster)
+		}
+
+		conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, DeletingHelmReleaseReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, err
+	}
+
+	// wait for the pods to terminate, as the statefulset would otherwise recreate the claims
+	terminating, err := r.podsTerminating(ctx, vCluster)
+	if err != nil {
+		conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, WaitingForPodsReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, err
+	} else if terminating {
+		r.Log.V(1).Info("waiting for vcluster pods to terminate",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
+		conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, WaitingForPodsReason, v1alpha1.ConditionSeverityInfo, "waiting for vcluster pods to terminate")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	// delete the persistent volume claims
+	err = r.deletePersistentVolumeClaims(ctx, vCluster)
+	if err != nil {
+		conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, DeletingPersistentVolumeClaimsReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, err
+	}
+
+	// delete the published ca config map
+	err = r.deleteCAConfigMap(ctx, vCluster)
+	if err != nil {
+		conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, DeletingCAConfigMapReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, err
+	}
+
+	// run the additional cleanup
+	err = r.runCleanupHooks(ctx, vCluster)
+	if err != nil {
+		conditions.MarkFalse(vCluster, v1alpha1.CleanupSucceededCondition, RunningCleanupHooksReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, err
+	}
+
+	r.forgetDeleted(vCluster)
+	done = true
+	return ctrl.Result{}, r.removeFinalizer(ctx, vCluster)
+}
+
+// forgetDeleted drops the state the reconciler keeps for the deleted vcluster
+func (r *VClusterReconciler) forgetDeleted(vCluster *v1alpha1.VCluster) {
+	nn := types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name}
+	r.resetDeployBackoff(nn)
+	r.resetStalled(nn)
+	r.forgetHelmRelease(nn)
+	r.forgetDriftCheck(nn)
+}
+
+// forceDelete checks if the vcluster should be removed although its helm release couldn't be deleted
+func forceDelete(vCluster *v1alpha1.VCluster) bool {
+	return vCluster.Annotations[ForceDeleteAnnotation] == "true" && vCluster.Status.FailedHelmDeleteAttempts >= ForceDeleteAttempts
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// DistroValuesMismatchReason is used when the helm values contain keys of a different vcluster distro.
+	DistroValuesMismatchReason = "DistroValuesMismatch"
+)
+
+// distroChartValues maps the vcluster distro charts to the top level value keys that are only
+// used by this distro
+var distroChartValues = map[string][]string{
+	"vcluster":     {"vcluster"},
+	"vcluster-k0s": {"vcluster"},
+	"vcluster-k8s": {"api", "controller", "etcd", "scheduler"},
+	"vcluster-eks": {"api", "controller", "etcd"},
+}
+
+// validateDistroValues warns if the helm values contain top level keys that only belong to a different
+// vcluster distro than the one of the chart, as they are silently ignored by the chart. This is advisory
+// and doesn't block the deployment.
+func (r *VClusterReconciler) validateDistroValues(vCluster *v1alpha1.VCluster) {
+	chartName := path.Base(getChartName(vCluster))
+	ownKeys, ok := distroChartValues[chartName]
+	if !ok || vCluster.Spec.HelmRelease == nil {
+		conditions.Delete(vCluster, v1alpha1.DistroValuesValidCondition)
+		return
+	}
+
+	values, err := vclustervalues.Parse(vCluster.Spec.HelmRelease.Values)
+	if err != nil {
+		// invalid values are reported by the deploy
+		conditions.Delete(vCluster, v1alpha1.DistroValuesValidCondition)
+		return
+	}
+
+	mismatched := []string{}
+	for key := range values {
+		if slices.Contains(ownKeys, key) {
+			continue
+		}
+
+		for distro, distroKeys := range distroChartValues {
+			if distro != chartName && slices.Contains(distroKeys, key) {
+				mismatched = append(mismatched, key)
+				break
+			}
+		}
+	}
+	if len(mismatched) == 0 {
+		conditions.MarkTrue(vCluster, v1alpha1.DistroValuesValidCondition)
+		return
+	}
+
+	sort.Strings(mismatched)
+	message := fmt.Sprintf("values %s are not used by chart %s and will be ignored", strings.Join(mismatched, ", "), chartName)
+	if !conditions.IsFalse(vCluster, v1alpha1.DistroValuesValidCondition) && r.Recorder != nil {
+		r.Recorder.Event(vCluster, corev1.EventTypeWarning, DistroValuesMismatchReason, message)
+	}
+	conditions.MarkFalse(vCluster, v1alpha1.DistroValuesValidCondition, DistroValuesMismatchReason, v1alpha1.ConditionSeverityWarning, "%s", message)
+}
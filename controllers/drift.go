@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+)
+
+const (
+	// DriftDetectedReason is used when the values of the deployed helm release differ from the desired values.
+	DriftDetectedReason = "DriftDetected"
+)
+
+// detectDrift compares the values of the deployed helm release to the desired values of the VCluster
+// once per DriftDetectionInterval and sets the DriftDetected condition. It returns true if the
+// release drifted.
+func (r *VClusterReconciler) detectDrift(ctx context.Context, vCluster *v1alpha1.VCluster, resolvedVersion string) (bool, error) {
+	name := types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name}
+	if r.DriftDetectionInterval <= 0 || r.HelmSecrets == nil || vCluster.Spec.HelmRelease == nil {
+		conditions.Delete(vCluster, v1alpha1.DriftDetectedCondition)
+		return false, nil
+	} else if vCluster.Status.LastHelmDeployTime.IsZero() {
+		// an adopted release wasn't deployed with the desired values, so it is only checked once
+		// the controller upgraded it
+		conditions.Delete(vCluster, v1alpha1.DriftDetectedCondition)
+		return false, nil
+	} else if !r.driftCheckDue(name) {
+		return false, nil
+	}
+
+	values, err := r.desiredValues(ctx, vCluster, getChartName(vCluster), getChartVersion(vCluster, resolvedVersion))
+	if err != nil {
+		return false, fmt.Errorf("determine desired values: %w", err)
+	}
+
+	var message string
+	release, err := r.HelmSecrets.Get(ctx, vCluster.Name, vCluster.Namespace)
+	if kerrors.IsNotFound(err) {
+		message = "helm release not found"
+	} else if err != nil {
+		return false, fmt.Errorf("get helm release: %w", err)
+	} else {
+		keys, err := driftedValues(values, release.Config)
+		if err != nil {
+			return false, err
+		} else if len(keys) > 0 {
+			message = fmt.Sprintf("values of helm release revision %d differ from the desired values at %s", release.Version, strings.Join(keys, ", "))
+		}
+	}
+
+	// only completed checks are recorded, failed checks are retried on the next reconcile
+	r.recordDriftCheck(name)
+	if message == "" {
+		conditions.Delete(vCluster, v1alpha1.DriftDetectedCondition)
+		return false, nil
+	}
+
+	r.Log.Info("helm release drifted",
+		"namespace", vCluster.Namespace,
+		"name", vCluster.Name,
+		"drift", message,
+	)
+	if !conditions.IsTrue(vCluster, v1alpha1.DriftDetectedCondition) && r.Recorder != nil {
+		r.Recorder.Event(vCluster, corev1.EventTypeWarning, DriftDetectedReason, message)
+	}
+	conditions.Set(vCluster, &v1alpha1.Condition{
+		Type:     v1alpha1.DriftDetectedCondition,
+		Status:   corev1.ConditionTrue,
+		Severity: v1alpha1.ConditionSeverityWarning,
+		Reason:   DriftDetectedReason,
+		Message:  message,
+	})
+	return true, nil
+}
+
+// driftedValues returns the sorted top level keys whose values differ between the desired values
+// yaml and the live values of the helm release
+func driftedValues(desired string, live map[string]interface{}) ([]string, error) {
+	desiredValues := map[string]interface{}{}
+	err := yaml.Unmarshal([]byte(desired), &desiredValues)
+	if err != nil {
+		return nil, fmt.Errorf("parse desired values: %w", err)
+	}
+
+	// round trip the live values through json to compare the same types
+	raw, err := json.Marshal(live)
+	if err != nil {
+		return nil, fmt.Errorf("marshal live values: %w", err)
+	}
+	liveValues := map[string]interface{}{}
+	err = json.Unmarshal(raw, &liveValues)
+	if err != nil {
+		return nil, fmt.Errorf("parse live values: %w", err)
+	}
+
+	keys := []string{}
+	for key, value := range desiredValues {
+		if !reflect.DeepEqual(value, liveValues[key]) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range liveValues {
+		if _, ok := desiredValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// driftCheckDue returns true if the last drift check of the VCluster is older than the drift
+// detection interval.
+func (r *VClusterReconciler) driftCheckDue(name types.NamespacedName) bool {
+	r.driftChecksMutex.Lock()
+	defer r.driftChecksMutex.Unlock()
+
+	last, ok := r.driftChecks[name]
+	return !ok || time.Since(last) >= r.DriftDetectionInterval
+}
+
+// recordDriftCheck records the time of a completed drift check of the VCluster.
+func (r *VClusterReconciler) recordDriftCheck(name types.NamespacedName) {
+	r.driftChecksMutex.Lock()
+	defer r.driftChecksMutex.Unlock()
+
+	if r.driftChecks == nil {
+		r.driftChecks = map[types.NamespacedName]time.Time{}
+	}
+	r.driftChecks[name] = time.Now()
+}
+
+// forgetDriftCheck drops the time of the last drift check of the VCluster.
+func (r *VClusterReconciler) forgetDriftCheck(name types.NamespacedName) {
+	r.driftChecksMutex.Lock()
+	defer r.driftChecksMutex.Unlock()
+
+	delete(r.driftChecks, name)
+}
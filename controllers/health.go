@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// HealthCheck fails if the newest successful reconcile across all VClusters is older than the
+// ReconcileStaleThreshold. It is healthy if the threshold is zero or no VCluster was reconciled yet.
+func (r *VClusterReconciler) HealthCheck(_ *http.Request) error {
+	if r.ReconcileStaleThreshold <= 0 {
+		return nil
+	}
+
+	r.lastReconciledMutex.Lock()
+	defer r.lastReconciledMutex.Unlock()
+
+	if len(r.lastReconciled) == 0 {
+		return nil
+	}
+
+	var newest time.Time
+	for _, t := range r.lastReconciled {
+		if t.After(newest) {
+			newest = t
+		}
+	}
+	if since := time.Since(newest); since > r.ReconcileStaleThreshold {
+		return fmt.Errorf("no VCluster was reconciled successfully for %s, threshold is %s", since.Round(time.Millisecond), r.ReconcileStaleThreshold)
+	}
+
+	return nil
+}
+
+// markReconciled remembers the time of the last successful reconcile of the VCluster.
+func (r *VClusterReconciler) markReconciled(name types.NamespacedName, t time.Time) {
+	r.lastReconciledMutex.Lock()
+	defer r.lastReconciledMutex.Unlock()
+
+	if r.lastReconciled == nil {
+		r.lastReconciled = map[types.NamespacedName]time.Time{}
+	}
+	r.lastReconciled[name] = t
+}
+
+// forgetReconciled removes a deleted, paused or moving VCluster from the last successful reconciles,
+// it is not reconciled until it is resumed.
+func (r *VClusterReconciler) forgetReconciled(name types.NamespacedName) {
+	r.lastReconciledMutex.Lock()
+	defer r.lastReconciledMutex.Unlock()
+
+	delete(r.lastReconciled, name)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+// helmBinary returns the helm binary the VCluster selected via annotation or an empty string to use
+// the binary of the helm client. The binary has to be one of the allowed helm binaries and an
+// executable file at an absolute path.
+func (r *VClusterReconciler) helmBinary(ctx context.Context, vCluster *v1alpha1.VCluster) (string, error) {
+	helmPath := vCluster.Annotations[HelmBinaryAnnotation]
+	if helmPath == "" {
+		return "", nil
+	} else if !filepath.IsAbs(helmPath) {
+		return "", fmt.Errorf("helm binary %s is no absolute path", helmPath)
+	} else if len(r.AllowedHelmBinaries) == 0 {
+		return "", fmt.Errorf("helm binary %s is not allowed, the controller allows no helm binaries besides the default one", helmPath)
+	} else if !slices.Contains(r.AllowedHelmBinaries, filepath.Clean(helmPath)) {
+		return "", fmt.Errorf("helm binary %s is not allowed, allowed binaries are: %s", helmPath, strings.Join(r.AllowedHelmBinaries, ", "))
+	}
+
+	info, err := os.Stat(helmPath)
+	if err != nil {
+		return "", fmt.Errorf("helm binary: %w", err)
+	} else if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return "", fmt.Errorf("helm binary %s is no executable file", helmPath)
+	}
+
+	r.logHelmVersion(ctx, helmPath)
+	return helmPath, nil
+}
+
+// logHelmVersion logs the version of a helm binary the first time it is used
+func (r *VClusterReconciler) logHelmVersion(ctx context.Context, helmPath string) {
+	r.helmBinariesMutex.Lock()
+	defer r.helmBinariesMutex.Unlock()
+
+	if r.helmBinaries == nil {
+		r.helmBinaries = map[string]bool{}
+	} else if r.helmBinaries[helmPath] {
+		return
+	}
+	r.helmBinaries[helmPath] = true
+
+	output, err := exec.CommandContext(ctx, helmPath, "version", "--short").CombinedOutput()
+	if err != nil {
+		r.Log.Info("error getting helm version", "helmBinary", helmPath, "err", err, "output", string(output))
+		return
+	}
+	r.Log.Info("using helm binary", "helmBinary", helmPath, "version", strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// lockHelmRelease serializes the helm operations of a release and returns the function to unlock it.
+// The lock is process-local, it doesn't protect against helm operations of other controller replicas
+// or helm invocations outside of the controller.
+func (r *VClusterReconciler) lockHelmRelease(name types.NamespacedName) func() {
+	r.helmLocksMutex.Lock()
+	if r.helmLocks == nil {
+		r.helmLocks = map[types.NamespacedName]*sync.Mutex{}
+	}
+	lock, ok := r.helmLocks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.helmLocks[name] = lock
+	}
+	r.helmLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// forgetHelmRelease removes the lock of a deleted release
+func (r *VClusterReconciler) forgetHelmRelease(name types.NamespacedName) {
+	r.helmLocksMutex.Lock()
+	defer r.helmLocksMutex.Unlock()
+
+	delete(r.helmLocks, name)
+}
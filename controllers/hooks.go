@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+)
+
+const (
+	// HelmHookFailedReason is used when a helm hook of the release failed.
+	HelmHookFailedReason = "HelmHookFailed"
+
+	// hookLogTailLines is the number of log lines of a failed hook that are added to the condition message
+	hookLogTailLines = 10
+)
+
+// reconcileHelmHooks inspects the hooks recorded in the helm release and reflects their results
+// in the HelmHooksSucceeded condition.
+func (r *VClusterReconciler) reconcileHelmHooks(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	if r.HelmSecrets == nil {
+		return nil
+	}
+
+	release, err := r.HelmSecrets.Get(ctx, vCluster.Name, vCluster.Namespace)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			conditions.Delete(vCluster, v1alpha1.HelmHooksSucceededCondition)
+			return nil
+		}
+
+		return err
+	}
+	if len(release.Hooks) == 0 {
+		conditions.Delete(vCluster, v1alpha1.HelmHooksSucceededCondition)
+		return nil
+	}
+
+	messages := []string{}
+	for _, hook := range release.Hooks {
+		if hook == nil || hook.LastRun.Phase != helm.HookPhaseFailed {
+			continue
+		}
+
+		message := fmt.Sprintf("hook %s %s failed", hook.Kind, hook.Name)
+		logs := r.hookLogs(ctx, vCluster.Namespace, hook)
+		if logs != "" {
+			message += ":\n" + logs
+		}
+		messages = append(messages, message)
+	}
+	if len(messages) > 0 {
+		conditions.MarkFalse(vCluster, v1alpha1.HelmHooksSucceededCondition, HelmHookFailedReason, v1alpha1.ConditionSeverityWarning, "%s", strings.Join(messages, "\n"))
+		return nil
+	}
+
+	conditions.MarkTrue(vCluster, v1alpha1.HelmHooksSucceededCondition)
+	return nil
+}
+
+// hookLogs returns the tail of the logs of the pod that ran the hook, errors are ignored
+// as the logs are only informational.
+func (r *VClusterReconciler) hookLogs(ctx context.Context, namespace string, hook *helm.Hook) string {
+	if r.HostClient == nil {
+		return ""
+	}
+
+	var podName string
+	switch hook.Kind {
+	case "Pod":
+		podName = hook.Name
+	case "Job":
+		podList := &corev1.PodList{}
+		err := r.Client.List(ctx, podList, client.InNamespace(namespace), client.MatchingLabels{"job-name": hook.Name})
+		if err != nil || len(podList.Items) == 0 {
+			return ""
+		}
+
+		// use the latest pod of the job
+		sort.Slice(podList.Items, func(i, j int) bool {
+			return podList.Items[j].CreationTimestamp.Before(&podList.Items[i].CreationTimestamp)
+		})
+		podName = podList.Items[0].Name
+	default:
+		return ""
+	}
+
+	stream, err := r.HostClient.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{TailLines: ptr.To[int64](hookLogTailLines)}).Stream(ctx)
+	if err != nil {
+		r.Log.V(1).Info("error retrieving hook logs", "namespace", namespace, "pod", podName, "err", err)
+		return ""
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(logs))
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm/repository"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// UnsupportedKubernetesVersionReason is used when the kubernetes version is not supported by the chart.
+	UnsupportedKubernetesVersionReason = "UnsupportedKubernetesVersion"
+
+	// chartMetadataCacheTTL is the time the parsed repository index is reused without a request
+	chartMetadataCacheTTL = time.Minute * 10
+)
+
+// kubernetesVersionRegEx matches the kubernetes version at the start of distro image tags
+// such as v1.31.1-k3s1 or v1.31.1-k0s.0
+var kubernetesVersionRegEx = regexp.MustCompile(`^v?(\d+\.\d+\.\d+)`)
+
+type ChartMetadataGetter interface {
+	ChartMetadata(ctx context.Context, repo, name, version string) (*helm.Metadata, error)
+}
+
+type chartMetadataGetter struct {
+}
+
+func (c *chartMetadataGetter) ChartMetadata(ctx context.Context, repo, name, version string) (*helm.Metadata, error) {
+	charts, err := repository.ParseRepositoryCached(ctx, &repository.Definition{Name: repo, URL: repo}, chartMetadataCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chart := range charts {
+		if chart.Metadata.Name != name {
+			continue
+		}
+
+		for _, meta := range chart.VersionsMeta {
+			if strings.TrimPrefix(meta.Version, "v") == version {
+				return &meta, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("chart %s with version %s not found in repository %s", name, version, repo)
+}
+
+func NewChartMetadataGetter() ChartMetadataGetter {
+	return &chartMetadataGetter{}
+}
+
+// requestedKubernetesVersion returns the kubernetes version of the distro image in the helm values
+// and falls back to the version that is currently running in the virtual cluster
+func requestedKubernetesVersion(vCluster *v1alpha1.VCluster, values map[string]interface{}) (string, string) {
+	distro := valuesDistro(values)
+	for _, tagPath := range [][]string{{"version"}, {"image", "tag"}, {"apiServer", "image", "tag"}} {
+		tag, _ := vclustervalues.Lookup(values, append([]string{"controlPlane", "distro", distro}, tagPath...)...).(string)
+		if tag != "" {
+			return tag, distro
+		}
+	}
+
+	return vCluster.Status.KubernetesVersion, distro
+}
+
+// chartMetadata returns the metadata of the chart that is installed. The metadata of a local chart
+// is read from its archive, the metadata of a repository chart is only looked up in the repository
+// index if a ChartMetadataGetter is configured. It returns nil if the metadata is not available.
+func (r *VClusterReconciler) chartMetadata(ctx context.Context, vCluster *v1alpha1.VCluster, chartRepo, chartName, chartVersion, chartPath string) (*helm.Metadata, error) {
+	if chartPath != "" {
+		archive, err := os.Open(chartPath)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+
+		return repository.ParseChartMetadata(archive)
+	}
+
+	// the repository index is fetched without the credentials of the repo secret
+	if r.ChartMetadataGetter == nil || strings.HasPrefix(chartRepo, "oci://") || vCluster.Spec.HelmRelease.Chart.RepoSecretRef != nil {
+		return nil, nil
+	}
+
+	return r.ChartMetadataGetter.ChartMetadata(ctx, chartRepo, chartName, chartVersion)
+}
+
+// validateKubernetesVersion checks the requested kubernetes version against the kubeVersion constraint
+// of the chart and prevents the deployment if the chart doesn't support it. The check is skipped if the
+// chart metadata can't be retrieved, e.g. for oci charts, as the deployment reports those errors anyway.
+func (r *VClusterReconciler) validateKubernetesVersion(ctx context.Context, vCluster *v1alpha1.VCluster, chartRepo, chartName, chartVersion, chartPath, values string) error {
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return err
+	}
+
+	requested, distro := requestedKubernetesVersion(vCluster, parsed)
+	matches := kubernetesVersionRegEx.FindStringSubmatch(requested)
+	if len(matches) < 2 {
+		conditions.Delete(vCluster, v1alpha1.KubernetesVersionSupportedCondition)
+		return nil
+	}
+
+	metadata, err := r.chartMetadata(ctx, vCluster, chartRepo, chartName, chartVersion, chartPath)
+	if err != nil {
+		r.Log.V(1).Info("error retrieving chart metadata, skipping kubernetes version check",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		conditions.Delete(vCluster, v1alpha1.KubernetesVersionSupportedCondition)
+		return nil
+	} else if metadata == nil {
+		conditions.Delete(vCluster, v1alpha1.KubernetesVersionSupportedCondition)
+		return nil
+	} else if metadata.KubeVersion == "" {
+		conditions.MarkTrue(vCluster, v1alpha1.KubernetesVersionSupportedCondition)
+		return nil
+	}
+
+	constraint, err := semver.NewConstraint(metadata.KubeVersion)
+	if err != nil {
+		return fmt.Errorf("parse kubeVersion %q of chart %s %s: %w", metadata.KubeVersion, chartName, chartVersion, err)
+	}
+	version, err := semver.NewVersion(matches[1])
+	if err != nil {
+		return fmt.Errorf("parse kubernetes version %s: %w", requested, err)
+	}
+	if !constraint.Check(version) {
+		err = fmt.Errorf("kubernetes version %s of distro %s is not supported by chart %s %s, supported versions are %s", requested, distro, chartName, chartVersion, metadata.KubeVersion)
+		conditions.MarkFalse(vCluster, v1alpha1.KubernetesVersionSupportedCondition, UnsupportedKubernetesVersionReason, v1alpha1.ConditionSeverityWarning, "%v", err)
+		return err
+	}
+
+	conditions.MarkTrue(vCluster, v1alpha1.KubernetesVersionSupportedCondition)
+	return nil
+}
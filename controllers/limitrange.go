@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+func limitRangeName(vCluster *v1alpha1.VCluster) string {
+	return vCluster.Name + "-limit-range"
+}
+
+func (r *VClusterReconciler) reconcileLimitRange(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      limitRangeName(vCluster),
+			Namespace: vCluster.Namespace,
+		},
+	}
+	if vCluster.Spec.LimitRange == nil {
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(limitRange), limitRange)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+
+			return fmt.Errorf("get limit range: %w", err)
+		} else if !metav1.IsControlledBy(limitRange, vCluster) {
+			return nil
+		}
+
+		// garbage collection would only remove it together with the vcluster
+		err = r.Client.Delete(ctx, limitRange)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("delete limit range: %w", err)
+		}
+
+		return nil
+	}
+
+	err := validateLimitRange(vCluster.Spec.LimitRange)
+	if err != nil {
+		return fmt.Errorf("invalid limit range: %w", err)
+	}
+
+	_, err = controllerutil.CreateOrPatch(ctx, r.Client, limitRange, func() error {
+		limitRange.Spec.Limits = []corev1.LimitRangeItem{
+			{
+				Type:           corev1.LimitTypeContainer,
+				Default:        vCluster.Spec.LimitRange.Default.DeepCopy(),
+				DefaultRequest: vCluster.Spec.LimitRange.DefaultRequest.DeepCopy(),
+				Max:            vCluster.Spec.LimitRange.Max.DeepCopy(),
+			},
+		}
+		return controllerutil.SetControllerReference(vCluster, limitRange, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("create limit range: %w", err)
+	}
+
+	return nil
+}
+
+// validateLimitRange makes sure the default requests don't exceed the default limits and
+// both don't exceed the maximum, as the api server would otherwise reject the LimitRange
+func validateLimitRange(limitRange *v1alpha1.VirtualClusterLimitRange) error {
+	for _, resourceList := range []corev1.ResourceList{limitRange.Default, limitRange.DefaultRequest, limitRange.Max} {
+		for name, quantity := range resourceList {
+			if quantity.Sign() < 0 {
+				return fmt.Errorf("%s must not be negative", name)
+			}
+		}
+	}
+
+	for name, request := range limitRange.DefaultRequest {
+		if limit, ok := limitRange.Default[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("default request %s of %s exceeds the default limit %s", request.String(), name, limit.String())
+		}
+		if max, ok := limitRange.Max[name]; ok && request.Cmp(max) > 0 {
+			return fmt.Errorf("default request %s of %s exceeds the maximum %s", request.String(), name, max.String())
+		}
+	}
+	for name, limit := range limitRange.Default {
+		if max, ok := limitRange.Max[name]; ok && limit.Cmp(max) > 0 {
+			return fmt.Errorf("default limit %s of %s exceeds the maximum %s", limit.String(), name, max.String())
+		}
+	}
+
+	return nil
+}
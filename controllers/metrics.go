@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// HelmOperationsTotalMetric counts the helm operations by operation and result
+	HelmOperationsTotalMetric = "capi_vcluster_helm_operations_total"
+
+	// HelmOperationDurationMetric observes the duration of the helm operations by operation
+	HelmOperationDurationMetric = "capi_vcluster_helm_operation_duration_seconds"
+
+	// ControlPlaneProbeDurationMetric observes the round trip of the control plane readiness and startup probes
+	ControlPlaneProbeDurationMetric = "capi_vcluster_control_plane_probe_duration_seconds"
+)
+
+var (
+	helmOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: HelmOperationsTotalMetric,
+		Help: "Total number of helm operations of the vcluster releases.",
+	}, []string{"operation", "result"})
+
+	helmOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    HelmOperationDurationMetric,
+		Help:    "Duration of the helm operations of the vcluster releases in seconds.",
+		Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"operation"})
+
+	controlPlaneProbeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    ControlPlaneProbeDurationMetric,
+		Help:    "Round trip of the control plane probes in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"path"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(helmOperationsTotal, helmOperationDuration, controlPlaneProbeDuration)
+}
+
+// observeHelmOperation records the result and duration of a helm operation that started at start
+func observeHelmOperation(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	helmOperationsTotal.WithLabelValues(operation, result).Inc()
+	helmOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
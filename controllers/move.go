@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+// blockMove marks a VCluster with a deployed helm release, so clusterctl move waits until the
+// controller stopped running helm operations for it
+func blockMove(vCluster *v1alpha1.VCluster) {
+	if _, ok := vCluster.Annotations[BlockMoveAnnotation]; ok {
+		return
+	}
+	if vCluster.Annotations == nil {
+		vCluster.Annotations = map[string]string{}
+	}
+
+	vCluster.Annotations[BlockMoveAnnotation] = "true"
+}
+
+// unblockMove removes the block-move annotation of a paused VCluster, as no helm operations run
+// for it anymore and clusterctl move can proceed
+func (r *VClusterReconciler) unblockMove(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	if _, ok := vCluster.Annotations[BlockMoveAnnotation]; !ok {
+		return nil
+	}
+
+	original := vCluster.DeepCopy()
+	delete(vCluster.Annotations, BlockMoveAnnotation)
+	return r.Client.Patch(ctx, vCluster, client.MergeFrom(original))
+}
+
+// isMoving checks if clusterctl move is deleting the VCluster from the source cluster, the helm
+// release lives on in the target cluster and must not be touched
+func isMoving(vCluster *v1alpha1.VCluster) bool {
+	_, ok := vCluster.Annotations[DeleteForMoveAnnotation]
+	return ok
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/cidrdiscovery"
+)
+
+const (
+	// NetworkPolicyLabel is set on every network policy managed for a vcluster and holds the vcluster name.
+	NetworkPolicyLabel = "vcluster.loft.sh/network-policy"
+)
+
+func (r *VClusterReconciler) reconcileNetworkPolicies(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	enabled := vCluster.Spec.NetworkPolicy != nil && vCluster.Spec.NetworkPolicy.Enabled
+	if enabled && !r.networkPolicyKindExists {
+		return fmt.Errorf("network policies are enabled, but the cluster does not support %s NetworkPolicy", networkingv1.SchemeGroupVersion.String())
+	}
+
+	desired := map[string]networkingv1.NetworkPolicySpec{}
+	if enabled {
+		templates := vCluster.Spec.NetworkPolicy.Policies
+		if len(templates) == 0 {
+			templates = defaultNetworkPolicies(vCluster, r.hostPodCIDRs(ctx))
+		}
+		for _, template := range templates {
+			desired[vCluster.Name+"-"+template.Name] = template.Spec
+		}
+	}
+
+	// delete policies that are not desired anymore
+	if r.networkPolicyKindExists {
+		policyList := &networkingv1.NetworkPolicyList{}
+		err := r.Client.List(ctx, policyList, client.InNamespace(vCluster.Namespace), client.MatchingLabels{NetworkPolicyLabel: vCluster.Name})
+		if err != nil {
+			return fmt.Errorf("list network policies: %w", err)
+		}
+		for i := range policyList.Items {
+			if _, ok := desired[policyList.Items[i].Name]; ok {
+				continue
+			}
+
+			err = r.Client.Delete(ctx, &policyList.Items[i])
+			if err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("delete network policy %s: %w", policyList.Items[i].Name, err)
+			}
+		}
+	}
+
+	for name, spec := range desired {
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: vCluster.Namespace,
+			},
+		}
+		_, err := controllerutil.CreateOrPatch(ctx, r.Client, policy, func() error {
+			if policy.ResourceVersion != "" && !ownsNetworkPolicy(vCluster, policy) {
+				return fmt.Errorf("network policy %s/%s exists and is not owned by the vcluster", policy.Namespace, policy.Name)
+			}
+
+			if policy.Labels == nil {
+				policy.Labels = map[string]string{}
+			}
+			policy.Labels[NetworkPolicyLabel] = vCluster.Name
+			policy.Spec = *spec.DeepCopy()
+			return controllerutil.SetControllerReference(vCluster, policy, r.Scheme)
+		})
+		if err != nil {
+			return fmt.Errorf("create network policy %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ownsNetworkPolicy returns true if the network policy is managed for the vcluster
+func ownsNetworkPolicy(vCluster *v1alpha1.VCluster, policy *networkingv1.NetworkPolicy) bool {
+	return policy.Labels[NetworkPolicyLabel] == vCluster.Name || metav1.IsControlledBy(policy, vCluster)
+}
+
+// hostPodCIDRs returns the pod CIDRs of the host cluster. Errors are only logged, as without
+// the pod CIDRs the default policies just don't allow egress outside of the namespace.
+func (r *VClusterReconciler) hostPodCIDRs(ctx context.Context) []string {
+	if r.HostClient == nil {
+		return nil
+	}
+
+	podCIDR, err := cidrdiscovery.GetPodCIDR(ctx, r.HostClient)
+	if err != nil {
+		r.Log.V(1).Info("error discovering the pod cidr, the network policies won't allow egress outside of the vcluster namespace", "err", err)
+		return nil
+	}
+
+	return strings.Split(podCIDR, ",")
+}
+
+// defaultNetworkPolicies returns a default-deny policy for the vcluster namespace together
+// with the allow rules the vcluster needs to function. If the pod CIDRs of the host cluster
+// are known, egress to addresses outside of the host pod network is allowed as well.
+func defaultNetworkPolicies(vCluster *v1alpha1.VCluster, podCIDRs []string) []v1alpha1.VirtualClusterNetworkPolicyTemplate {
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+	dnsPort := intstr.FromInt32(53)
+	controlPlanePort := intstr.FromInt32(8443)
+
+	policies := []v1alpha1.VirtualClusterNetworkPolicyTemplate{
+		{
+			Name: "default-deny",
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		},
+		{
+			Name: "allow-namespace",
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+				},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		},
+		{
+			Name: "allow-control-plane",
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app":     "vcluster",
+						"release": vCluster.Name,
+					},
+				},
+				// the control plane must be reachable from outside and needs to reach the host api server
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &controlPlanePort}}},
+				},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{},
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		},
+		{
+			Name: "allow-dns",
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{
+						To: []networkingv1.NetworkPolicyPeer{
+							{
+								NamespaceSelector: &metav1.LabelSelector{},
+								PodSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+								},
+							},
+						},
+						Ports: []networkingv1.NetworkPolicyPort{
+							{Protocol: &udp, Port: &dnsPort},
+							{Protocol: &tcp, Port: &dnsPort},
+						},
+					},
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			},
+		},
+	}
+
+	egress := podNetworkExcludingPeers(podCIDRs)
+	if len(egress) > 0 {
+		policies = append(policies, v1alpha1.VirtualClusterNetworkPolicyTemplate{
+			Name: "allow-egress",
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{To: egress},
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			},
+		})
+	}
+
+	return policies
+}
+
+// podNetworkExcludingPeers returns peers for all addresses except the given pod CIDRs, one per
+// ip family of the pod CIDRs. No peers are returned if a pod CIDR is invalid.
+func podNetworkExcludingPeers(podCIDRs []string) []networkingv1.NetworkPolicyPeer {
+	var ipv4, ipv6 []string
+	for _, podCIDR := range podCIDRs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(podCIDR))
+		if err != nil {
+			return nil
+		}
+
+		if ipNet.IP.To4() != nil {
+			ipv4 = append(ipv4, ipNet.String())
+		} else {
+			ipv6 = append(ipv6, ipNet.String())
+		}
+	}
+
+	var peers []networkingv1.NetworkPolicyPeer
+	if len(ipv4) > 0 {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: ipv4}})
+	}
+	if len(ipv6) > 0 {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: "::/0", Except: ipv6}})
+	}
+	return peers
+}
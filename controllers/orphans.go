@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+)
+
+// warnOrphanedReleases logs the vcluster helm releases in the namespace that have no VCluster, e.g. because
+// the VCluster was deleted without its finalizer. Every orphaned release is only logged once until it gets a
+// VCluster again. The releases are only reported and never deleted.
+func (r *VClusterReconciler) warnOrphanedReleases(ctx context.Context, namespace string) error {
+	if !r.WarnOrphanedReleases || r.HelmSecrets == nil {
+		return nil
+	}
+
+	releases, err := r.HelmSecrets.ListVClusterReleases(ctx, namespace)
+	if err != nil {
+		return fmt.Errorf("list vcluster releases: %w", err)
+	}
+
+	vClusterList := &v1alpha1.VClusterList{}
+	err = r.Client.List(ctx, vClusterList, client.InNamespace(namespace))
+	if err != nil {
+		return fmt.Errorf("list vclusters: %w", err)
+	}
+	vClusters := map[string]bool{}
+	for _, vCluster := range vClusterList.Items {
+		vClusters[vCluster.Name] = true
+	}
+
+	orphaned := map[string]int{}
+	for _, release := range releases {
+		if !vClusters[release.Name] {
+			orphaned[release.Name] = release.Version
+		}
+	}
+
+	for _, name := range r.recordOrphanedReleases(namespace, orphaned) {
+		r.Log.Info("vcluster helm release has no VCluster",
+			"namespace", namespace,
+			"release", name,
+			"revision", orphaned[name],
+		)
+	}
+
+	return nil
+}
+
+// recordOrphanedReleases replaces the recorded orphaned releases of the namespace and returns the
+// sorted names of the releases that were not recorded before
+func (r *VClusterReconciler) recordOrphanedReleases(namespace string, orphaned map[string]int) []string {
+	r.orphanedReleasesMutex.Lock()
+	defer r.orphanedReleasesMutex.Unlock()
+
+	if r.orphanedReleases == nil {
+		r.orphanedReleases = map[string]map[string]bool{}
+	}
+
+	names := []string{}
+	recorded := map[string]bool{}
+	for name := range orphaned {
+		if !r.orphanedReleases[namespace][name] {
+			names = append(names, name)
+		}
+		recorded[name] = true
+	}
+	r.orphanedReleases[namespace] = recorded
+	sort.Strings(names)
+
+	return names
+}
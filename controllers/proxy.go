@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+// proxyEnvValues returns the paths of the env variables of the control plane containers in the helm
+// values. Charts before 0.20 and the distro charts configure every container on its own.
+func proxyEnvValues(chartName, chartVersion string) [][]string {
+	switch path.Base(chartName) {
+	case "vcluster":
+		version, err := semver.NewVersion(chartVersion)
+		if err == nil && version.LessThan(semver.MustParse("0.20.0-alpha.0")) {
+			return [][]string{{"vcluster", "env"}, {"syncer", "env"}}
+		}
+
+		return [][]string{{"controlPlane", "statefulSet", "env"}}
+	case "vcluster-k8s", "vcluster-eks":
+		return [][]string{{"api", "env"}, {"controller", "env"}, {"syncer", "env"}}
+	default:
+		return [][]string{{"vcluster", "env"}, {"syncer", "env"}}
+	}
+}
+
+// mergeProxyValues adds the proxy environment variables of the VCluster spec to the env of the control
+// plane containers in the helm values. Env variables with the same name in the values are replaced.
+func mergeProxyValues(vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) (string, error) {
+	proxy := vCluster.Spec.Proxy
+	if proxy == nil {
+		return values, nil
+	}
+
+	proxyEnv := map[string]string{}
+	for name, proxyURL := range map[string]string{"HTTP_PROXY": proxy.HTTPProxy, "HTTPS_PROXY": proxy.HTTPSProxy} {
+		if proxyURL == "" {
+			continue
+		}
+
+		err := validateProxyURL(proxyURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s %s: %w", strings.ToLower(name), proxyURL, err)
+		}
+		proxyEnv[name] = proxyURL
+	}
+	if len(proxy.NoProxy) > 0 {
+		proxyEnv["NO_PROXY"] = strings.Join(proxy.NoProxy, ",")
+	}
+	if len(proxyEnv) == 0 {
+		return values, nil
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	for _, envPath := range proxyEnvValues(chartName, chartVersion) {
+		// keep the existing env variables that are not set by the proxy configuration
+		env := []interface{}{}
+		existingEnv, _ := vclustervalues.Lookup(parsed, envPath...).([]interface{})
+		for _, e := range existingEnv {
+			if envMap, ok := e.(map[string]interface{}); ok {
+				if name, _ := envMap["name"].(string); name != "" && proxyEnv[strings.ToUpper(name)] != "" {
+					continue
+				}
+			}
+
+			env = append(env, e)
+		}
+		for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"} {
+			if proxyEnv[name] == "" {
+				continue
+			}
+
+			env = append(env, map[string]interface{}{"name": name, "value": proxyEnv[name]})
+		}
+
+		var override interface{} = env
+		for i := len(envPath) - 1; i > 0; i-- {
+			override = map[string]interface{}{envPath[i]: override}
+		}
+		values, err = vclustervalues.Merge(values, map[string]interface{}{envPath[0]: override})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return values, nil
+}
+
+func validateProxyURL(proxyURL string) error {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("host is missing")
+	}
+
+	return nil
+}
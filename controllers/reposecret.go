@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+)
+
+// setRepoCredentials sets the repository credentials of the Secret referenced by the chart of the
+// VCluster on the upgrade options. The username and password keys are required, ca.crt is optional.
+func (r *VClusterReconciler) setRepoCredentials(ctx context.Context, vCluster *v1alpha1.VCluster, options *helm.UpgradeOptions) error {
+	if vCluster.Spec.HelmRelease == nil || vCluster.Spec.HelmRelease.Chart.RepoSecretRef == nil {
+		return nil
+	}
+
+	name := vCluster.Spec.HelmRelease.Chart.RepoSecretRef.Name
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: vCluster.Namespace, Name: name}, secret)
+	if err != nil {
+		return fmt.Errorf("get repo secret %s: %w", name, err)
+	}
+
+	for _, key := range []string{corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey} {
+		if len(secret.Data[key]) == 0 {
+			return fmt.Errorf("repo secret %s has no key %s", name, key)
+		}
+	}
+	options.Username = string(secret.Data[corev1.BasicAuthUsernameKey])
+	options.Password = string(secret.Data[corev1.BasicAuthPasswordKey])
+	options.CAData = string(secret.Data[CACertDataName])
+	return nil
+}
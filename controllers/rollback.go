@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+)
+
+const (
+	// HelmRollbackReason is used for the event of a rollback after a failed upgrade.
+	HelmRollbackReason = "HelmRollback"
+
+	// HelmRollbackFailedReason is used for the event of a rollback that failed.
+	HelmRollbackFailedReason = "HelmRollbackFailed"
+)
+
+// rollbackFailedUpgrade rolls the release back to the last deployed revision if the VCluster opted in
+// via annotation. Errors are only reported, as the failed upgrade is reported by the caller anyway.
+func (r *VClusterReconciler) rollbackFailedUpgrade(ctx context.Context, vCluster *v1alpha1.VCluster, upgradeErr error) {
+	if vCluster.Annotations[RollbackOnFailureAnnotation] != "true" || r.HelmSecrets == nil {
+		return
+	}
+
+	// the upgrade may have failed before a new revision was created
+	latest, err := r.HelmSecrets.Get(ctx, vCluster.Name, vCluster.Namespace)
+	if kerrors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		r.Log.Info("error retrieving helm release",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		return
+	} else if latest.Info == nil || latest.Info.Status == helm.StatusDeployed {
+		return
+	}
+
+	// a failed upgrade keeps the previous revision deployed
+	revision, err := r.HelmSecrets.LastDeployedRevision(ctx, vCluster.Name, vCluster.Namespace)
+	if kerrors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		r.Log.Info("error retrieving helm release history",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		return
+	}
+
+	r.Log.Info("roll back failed helm upgrade",
+		"namespace", vCluster.Namespace,
+		"name", vCluster.Name,
+		"revision", revision,
+	)
+	start := time.Now()
+	err = r.HelmClient.Rollback(ctx, vCluster.Name, vCluster.Namespace, strconv.Itoa(revision))
+	observeHelmOperation("rollback", start, err)
+	if err != nil {
+		r.Log.Error(err, "error rolling back helm release",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"revision", revision,
+		)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, HelmRollbackFailedReason, "rollback to revision %d failed: %v", revision, err)
+		}
+		return
+	}
+
+	if r.Recorder != nil {
+		r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, HelmRollbackReason, "rolled back to revision %d after failed upgrade: %v", revision, upgradeErr)
+	}
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"path"
+
+	"github.com/Masterminds/semver"
+	corev1 "k8s.io/api/core/v1"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// ServiceAccountNameConflictReason is used when the helm values set a different service account than the spec.
+	ServiceAccountNameConflictReason = "ServiceAccountNameConflict"
+)
+
+// serviceAccountValues returns the path of the control plane service account in the helm values and the
+// key that disables its creation by the chart. Charts before 0.20 and the distro charts use the top level.
+func serviceAccountValues(chartName, chartVersion string) (parent []string, enabled string) {
+	if path.Base(chartName) != "vcluster" {
+		return []string{"serviceAccount"}, "create"
+	}
+
+	version, err := semver.NewVersion(chartVersion)
+	if err == nil && version.LessThan(semver.MustParse("0.20.0-alpha.0")) {
+		return []string{"serviceAccount"}, "create"
+	}
+
+	return []string{"controlPlane", "advanced", "serviceAccount"}, "enabled"
+}
+
+// mergeServiceAccountValues sets the service account of the VCluster spec in the helm values. If the
+// values already set a different service account, the values win.
+func mergeServiceAccountValues(vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) (string, error) {
+	serviceAccountName := vCluster.Spec.ServiceAccountName
+	if serviceAccountName == "" {
+		return values, nil
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	parent, enabledKey := serviceAccountValues(chartName, chartVersion)
+	if existing, _ := vclustervalues.Lookup(parsed, append(parent, "name")...).(string); existing != "" {
+		return values, nil
+	}
+
+	var override interface{} = map[string]interface{}{
+		enabledKey: false,
+		"name":     serviceAccountName,
+	}
+	for i := len(parent) - 1; i > 0; i-- {
+		override = map[string]interface{}{parent[i]: override}
+	}
+
+	return vclustervalues.Merge(values, map[string]interface{}{parent[0]: override})
+}
+
+// warnServiceAccountConflict emits a warning if the deployed helm values set a different service
+// account than the VCluster spec. It is called on deploys only, so the warning isn't repeated by
+// the drift checks.
+func (r *VClusterReconciler) warnServiceAccountConflict(vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) {
+	serviceAccountName := vCluster.Spec.ServiceAccountName
+	if serviceAccountName == "" {
+		return
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return
+	}
+
+	parent, _ := serviceAccountValues(chartName, chartVersion)
+	existing, _ := vclustervalues.Lookup(parsed, append(parent, "name")...).(string)
+	if existing == "" || existing == serviceAccountName {
+		return
+	}
+
+	r.Log.Info("helm values set a different service account than the spec, using the one of the values",
+		"namespace", vCluster.Namespace,
+		"name", vCluster.Name,
+		"serviceAccount", existing,
+	)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, ServiceAccountNameConflictReason, "helm values set service account %s, ignoring spec.serviceAccountName %s", existing, serviceAccountName)
+	}
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+)
+
+const (
+	// StalledReconcileReason is used when the VCluster spec could not be reconciled for longer than the stalled timeout.
+	StalledReconcileReason = "ReconcileStalled"
+)
+
+// reconcileStalled sets the StalledReconcile condition and emits a warning event when the
+// VCluster generation could not be reconciled for longer than the stalled timeout.
+func (r *VClusterReconciler) reconcileStalled(vCluster *v1alpha1.VCluster, converged bool) {
+	name := types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name}
+	if converged || r.StalledReconcileTimeout <= 0 {
+		r.resetStalled(name)
+		conditions.Delete(vCluster, v1alpha1.StalledReconcileCondition)
+		return
+	}
+
+	since := r.stalledSince(name)
+	if time.Since(since) < r.StalledReconcileTimeout {
+		return
+	}
+
+	message := fmt.Sprintf("generation %d has not been reconciled since %s, last reconciled generation is %d", vCluster.Generation, since.Format(time.RFC3339), vCluster.Status.ObservedGeneration)
+	if !conditions.IsTrue(vCluster, v1alpha1.StalledReconcileCondition) && r.Recorder != nil {
+		r.Recorder.Event(vCluster, corev1.EventTypeWarning, StalledReconcileReason, message)
+	}
+	conditions.Set(vCluster, &v1alpha1.Condition{
+		Type:     v1alpha1.StalledReconcileCondition,
+		Status:   corev1.ConditionTrue,
+		Severity: v1alpha1.ConditionSeverityWarning,
+		Reason:   StalledReconcileReason,
+		Message:  message,
+	})
+}
+
+// stalledSince returns the time since when the VCluster could not be reconciled.
+func (r *VClusterReconciler) stalledSince(name types.NamespacedName) time.Time {
+	r.stalledMutex.Lock()
+	defer r.stalledMutex.Unlock()
+
+	if r.stalled == nil {
+		r.stalled = map[types.NamespacedName]time.Time{}
+	}
+	if _, ok := r.stalled[name]; !ok {
+		r.stalled[name] = time.Now()
+	}
+
+	return r.stalled[name]
+}
+
+// resetStalled forgets the time since when the VCluster could not be reconciled.
+func (r *VClusterReconciler) resetStalled(name types.NamespacedName) {
+	r.stalledMutex.Lock()
+	defer r.stalledMutex.Unlock()
+
+	delete(r.stalled, name)
+}
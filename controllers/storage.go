@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"path"
+
+	"github.com/Masterminds/semver"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// StorageClassNotFoundReason is used when the StorageClass of the spec does not exist.
+	StorageClassNotFoundReason = "StorageClassNotFound"
+)
+
+// storageValues returns the path of the persistent volume in the helm values and the keys of the
+// StorageClass and the size. Charts before 0.20 and the distro charts use the top level storage.
+func storageValues(chartName, chartVersion string) (parent []string, className string, size string) {
+	if path.Base(chartName) != "vcluster" {
+		return []string{"storage"}, "className", "size"
+	}
+
+	version, err := semver.NewVersion(chartVersion)
+	if err == nil && version.LessThan(semver.MustParse("0.20.0-alpha.0")) {
+		return []string{"storage"}, "className", "size"
+	}
+
+	return []string{"controlPlane", "statefulSet", "persistence", "volumeClaim"}, "storageClass", "size"
+}
+
+// mergeStorageValues sets the storage of the VCluster spec in the helm values, storage values that are
+// already set in the helm values take precedence.
+func mergeStorageValues(vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) (string, error) {
+	storage := vCluster.Spec.Storage
+	if storage == nil || (storage.ClassName == "" && storage.Size == nil) {
+		return values, nil
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	parent, classNameKey, sizeKey := storageValues(chartName, chartVersion)
+	volume := map[string]interface{}{}
+	if storage.ClassName != "" && vclustervalues.Lookup(parsed, append(parent, classNameKey)...) == nil {
+		volume[classNameKey] = storage.ClassName
+	}
+	if storage.Size != nil && vclustervalues.Lookup(parsed, append(parent, sizeKey)...) == nil {
+		volume[sizeKey] = storage.Size.String()
+	}
+	if len(volume) == 0 {
+		return values, nil
+	}
+
+	var override interface{} = volume
+	for i := len(parent) - 1; i > 0; i-- {
+		override = map[string]interface{}{parent[i]: override}
+	}
+
+	return vclustervalues.Merge(values, map[string]interface{}{parent[0]: override})
+}
+
+// checkStorageClass emits a warning if the StorageClass of the spec is deployed but does not exist. The
+// deploy is not blocked, as the StorageClass might be created later. It is called on deploys only, so
+// the warning isn't repeated by the drift checks.
+func (r *VClusterReconciler) checkStorageClass(ctx context.Context, vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) {
+	storage := vCluster.Spec.Storage
+	if storage == nil || storage.ClassName == "" {
+		return
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return
+	}
+
+	parent, classNameKey, _ := storageValues(chartName, chartVersion)
+	className, _ := vclustervalues.Lookup(parsed, append(parent, classNameKey)...).(string)
+	if className != storage.ClassName {
+		return
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: className}, &storagev1.StorageClass{})
+	if kerrors.IsNotFound(err) {
+		r.Log.Info("storage class of the vcluster does not exist",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"storageClass", className,
+		)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, StorageClassNotFoundReason, "storage class %s does not exist", className)
+		}
+	} else if err != nil {
+		r.Log.V(1).Info("error checking the storage class of the vcluster",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"storageClass", className,
+			"err", err,
+		)
+	}
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/Masterminds/semver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// ZoneTopologyKey is the node label used to spread the control plane across zones
+	ZoneTopologyKey = "topology.kubernetes.io/zone"
+)
+
+// topologySpreadComponent is a control plane component that has its own scheduling in the helm values
+type topologySpreadComponent struct {
+	// parent is the path of the topologySpreadConstraints in the helm values
+	parent []string
+	// replicas is the path of the replicas of the component in the helm values
+	replicas []string
+	// app is the app label of the component pods
+	app string
+}
+
+// topologySpreadValues returns the control plane components of the chart. Charts before 0.20 use the top
+// level scheduling, the k8s and eks distro charts schedule every component on its own.
+func topologySpreadValues(chartName, chartVersion string) []topologySpreadComponent {
+	switch path.Base(chartName) {
+	case "vcluster":
+		version, err := semver.NewVersion(chartVersion)
+		if err == nil && version.LessThan(semver.MustParse("0.20.0-alpha.0")) {
+			return []topologySpreadComponent{{replicas: []string{"replicas"}, app: "vcluster"}}
+		}
+
+		return []topologySpreadComponent{{
+			parent:   []string{"controlPlane", "statefulSet", "scheduling"},
+			replicas: []string{"controlPlane", "statefulSet", "highAvailability", "replicas"},
+			app:      "vcluster",
+		}}
+	case "vcluster-k8s", "vcluster-eks":
+		return []topologySpreadComponent{
+			{parent: []string{"api"}, replicas: []string{"api", "replicas"}, app: "vcluster-api"},
+			{parent: []string{"controller"}, replicas: []string{"controller", "replicas"}, app: "vcluster-controller"},
+			{parent: []string{"etcd"}, replicas: []string{"etcd", "replicas"}, app: "vcluster-etcd"},
+			{parent: []string{"syncer"}, replicas: []string{"syncer", "replicas"}, app: "vcluster"},
+		}
+	default:
+		return []topologySpreadComponent{{replicas: []string{"replicas"}, app: "vcluster"}}
+	}
+}
+
+// mergeTopologySpreadValues adds the topology spread constraints of the VCluster spec to the scheduling
+// of the control plane components in the helm values. Constraints in the values are replaced.
+func mergeTopologySpreadValues(vCluster *v1alpha1.VCluster, chartName, chartVersion, values string) (string, error) {
+	topologySpread := vCluster.Spec.TopologySpread
+	if topologySpread == nil {
+		return values, nil
+	}
+
+	for i, constraint := range topologySpread.Constraints {
+		err := validateTopologySpreadConstraint(constraint)
+		if err != nil {
+			return "", fmt.Errorf("invalid topology spread constraint %d: %w", i, err)
+		}
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	for _, component := range topologySpreadValues(chartName, chartVersion) {
+		constraints := append([]corev1.TopologySpreadConstraint{}, topologySpread.Constraints...)
+
+		// spreading a single replica doesn't do anything, so we only add the zone constraint for HA setups
+		if topologySpread.ZoneSpread && controlPlaneReplicas(parsed, component.replicas) > 1 {
+			constraints = append(constraints, corev1.TopologySpreadConstraint{
+				MaxSkew:           1,
+				TopologyKey:       ZoneTopologyKey,
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app":     component.app,
+						"release": vCluster.Name,
+					},
+				},
+			})
+		}
+		if len(constraints) == 0 {
+			continue
+		}
+
+		// convert the constraints into plain values
+		raw, err := json.Marshal(constraints)
+		if err != nil {
+			return "", err
+		}
+		constraintValues := []interface{}{}
+		err = json.Unmarshal(raw, &constraintValues)
+		if err != nil {
+			return "", err
+		}
+
+		override := map[string]interface{}{"topologySpreadConstraints": constraintValues}
+		for i := len(component.parent) - 1; i >= 0; i-- {
+			override = map[string]interface{}{component.parent[i]: override}
+		}
+		values, err = vclustervalues.Merge(values, override)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return values, nil
+}
+
+// controlPlaneReplicas returns the number of replicas at the given path of the values, defaults to 1
+func controlPlaneReplicas(values map[string]interface{}, replicasPath []string) int64 {
+	switch replicas := vclustervalues.Lookup(values, replicasPath...).(type) {
+	case float64:
+		return int64(replicas)
+	case int64:
+		return replicas
+	}
+
+	return 1
+}
+
+func validateTopologySpreadConstraint(constraint corev1.TopologySpreadConstraint) error {
+	if constraint.MaxSkew < 1 {
+		return fmt.Errorf("maxSkew must be greater than zero")
+	}
+	if constraint.TopologyKey == "" {
+		return fmt.Errorf("topologyKey is missing")
+	}
+	if constraint.WhenUnsatisfiable != corev1.DoNotSchedule && constraint.WhenUnsatisfiable != corev1.ScheduleAnyway {
+		return fmt.Errorf("whenUnsatisfiable must be %s or %s", corev1.DoNotSchedule, corev1.ScheduleAnyway)
+	}
+	if constraint.MinDomains != nil && *constraint.MinDomains < 1 {
+		return fmt.Errorf("minDomains must be greater than zero")
+	}
+
+	return nil
+}
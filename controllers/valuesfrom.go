@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+// DefaultValuesFromKey is the key that is used if a values source doesn't specify one
+const DefaultValuesFromKey = "values.yaml"
+
+// mergeValuesFrom merges the values of the referenced Secrets and ConfigMaps in order and the inline
+// values on top of them. Lists are merged according to the values merge strategies of the VCluster.
+func (r *VClusterReconciler) mergeValuesFrom(ctx context.Context, vCluster *v1alpha1.VCluster, values string) (string, error) {
+	if vCluster.Spec.HelmRelease == nil || len(vCluster.Spec.HelmRelease.ValuesFrom) == 0 {
+		return values, nil
+	}
+
+	merged := ""
+	strategies := valuesMergeStrategies(vCluster)
+	for _, source := range vCluster.Spec.HelmRelease.ValuesFrom {
+		sourceValues, err := r.getValuesSource(ctx, vCluster.Namespace, source)
+		if err != nil {
+			return "", err
+		}
+
+		parsed, err := vclustervalues.Parse(sourceValues)
+		if err != nil {
+			return "", fmt.Errorf("%s %s: %w", source.Kind, source.Name, err)
+		}
+
+		merged, err = vclustervalues.MergeWithStrategies(merged, parsed, strategies)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	return vclustervalues.MergeWithStrategies(merged, parsed, strategies)
+}
+
+// valuesMergeStrategies returns the merge strategies of the helm values of the vcluster
+func valuesMergeStrategies(vCluster *v1alpha1.VCluster) map[string]vclustervalues.MergeStrategy {
+	strategies := map[string]vclustervalues.MergeStrategy{}
+	for path, strategy := range vCluster.Spec.HelmRelease.ValuesMergeStrategies {
+		strategies[path] = vclustervalues.MergeStrategy(strategy)
+	}
+
+	return strategies
+}
+
+// valuesFromHash returns the hash of the data of the referenced values sources or an empty string
+// if there are none. A changed hash redeploys the virtual cluster.
+func (r *VClusterReconciler) valuesFromHash(ctx context.Context, vCluster *v1alpha1.VCluster) (string, error) {
+	if vCluster.Spec.HelmRelease == nil || len(vCluster.Spec.HelmRelease.ValuesFrom) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, source := range vCluster.Spec.HelmRelease.ValuesFrom {
+		sourceValues, err := r.getValuesSource(ctx, vCluster.Namespace, source)
+		if err != nil {
+			return "", err
+		}
+
+		_, _ = fmt.Fprintf(hash, "%s/%s/%s\n%d\n%s", source.Kind, source.Name, source.Key, len(sourceValues), sourceValues)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ValuesSourceIndex indexes VClusters by the Secrets and ConfigMaps they reference as values sources
+const ValuesSourceIndex = "spec.helmRelease.valuesFrom"
+
+// ValuesSourceIndexValues returns the values sources of a VCluster as <kind>/<name> for the ValuesSourceIndex
+func ValuesSourceIndexValues(obj client.Object) []string {
+	vCluster, ok := obj.(*v1alpha1.VCluster)
+	if !ok || vCluster.Spec.HelmRelease == nil {
+		return nil
+	}
+
+	var values []string
+	for _, source := range vCluster.Spec.HelmRelease.ValuesFrom {
+		values = append(values, source.Kind+"/"+source.Name)
+	}
+	return values
+}
+
+// VClustersForValuesSource returns the VClusters in the namespace of the Secret or ConfigMap that
+// reference it as values source. The object might only hold the metadata of the Secret or ConfigMap.
+func (r *VClusterReconciler) VClustersForValuesSource(ctx context.Context, obj client.Object) []reconcile.Request {
+	var kind string
+	switch obj.(type) {
+	case *corev1.Secret:
+		kind = "Secret"
+	case *corev1.ConfigMap:
+		kind = "ConfigMap"
+	case *metav1.PartialObjectMetadata:
+		kind = obj.GetObjectKind().GroupVersionKind().Kind
+	}
+	if kind != "Secret" && kind != "ConfigMap" {
+		return nil
+	}
+
+	vClusters := &v1alpha1.VClusterList{}
+	err := r.Client.List(ctx, vClusters, client.InNamespace(obj.GetNamespace()), client.MatchingFields{ValuesSourceIndex: kind + "/" + obj.GetName()})
+	if err != nil {
+		r.Log.Error(err, "error listing vclusters of values source",
+			"namespace", obj.GetNamespace(),
+			"name", obj.GetName(),
+		)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, vCluster := range vClusters.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name}})
+	}
+
+	return requests
+}
+
+// notHelmReleasePredicate filters the Secrets that hold helm releases, they change on every deploy
+// and are never values sources
+func notHelmReleasePredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()["owner"] != "helm"
+	})
+}
+
+func (r *VClusterReconciler) getValuesSource(ctx context.Context, namespace string, source v1alpha1.VirtualClusterValuesSource) (string, error) {
+	key := source.Key
+	if key == "" {
+		key = DefaultValuesFromKey
+	}
+
+	return r.getSourceData(ctx, namespace, "values", source.Kind, source.Name, key)
+}
+
+// getSourceData returns the data of the key in the referenced Secret or ConfigMap, purpose is
+// used in the error messages
+func (r *VClusterReconciler) getSourceData(ctx context.Context, namespace, purpose, kind, name, key string) (string, error) {
+	objectKey := types.NamespacedName{Namespace: namespace, Name: name}
+	switch kind {
+	case "Secret":
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, objectKey, secret)
+		if err != nil {
+			return "", fmt.Errorf("get %s secret %s: %w", purpose, name, err)
+		}
+
+		data, ok := secret.Data[key]
+		if !ok {
+			return "", fmt.Errorf("%s secret %s has no key %s", purpose, name, key)
+		}
+
+		return string(data), nil
+	case "ConfigMap":
+		configMap := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, objectKey, configMap)
+		if err != nil {
+			return "", fmt.Errorf("get %s config map %s: %w", purpose, name, err)
+		}
+
+		data, ok := configMap.Data[key]
+		if !ok {
+			return "", fmt.Errorf("%s config map %s has no key %s", purpose, name, key)
+		}
+
+		return data, nil
+	default:
+		return "", fmt.Errorf("unsupported %s source kind %q, must be Secret or ConfigMap", purpose, kind)
+	}
+}
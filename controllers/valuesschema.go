@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm/repository"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/valuesschema"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// InvalidValuesReason is used when the helm values violate the values schema of the chart.
+	InvalidValuesReason = "InvalidValues"
+)
+
+type ValuesSchemaGetter interface {
+	// ValuesSchema returns the values.schema.json of the chart or an empty string if the chart has none
+	ValuesSchema(ctx context.Context, repo, name, version string) (string, error)
+}
+
+type valuesSchemaGetter struct {
+	metadataGetter ChartMetadataGetter
+}
+
+func (v *valuesSchemaGetter) ValuesSchema(ctx context.Context, repo, name, version string) (string, error) {
+	metadata, err := v.metadataGetter.ChartMetadata(ctx, repo, name, version)
+	if err != nil {
+		return "", err
+	}
+
+	return repository.ParseValuesSchema(ctx, &helm.Chart{
+		Metadata:   *metadata,
+		Repository: helm.ChartRepository{Name: repo, URL: repo},
+	})
+}
+
+func NewValuesSchemaGetter() ValuesSchemaGetter {
+	return &valuesSchemaGetter{metadataGetter: NewChartMetadataGetter()}
+}
+
+// validateValuesSchema validates the helm values against the values schema of the chart and reports
+// violations as warning, as helm itself only validates the values during the install. The check is
+// skipped if the chart has no schema or it can't be retrieved, e.g. for oci charts.
+func (r *VClusterReconciler) validateValuesSchema(ctx context.Context, vCluster *v1alpha1.VCluster, chartRepo, chartName, chartVersion, values string) {
+	if r.ValuesSchemaGetter == nil || strings.HasPrefix(chartRepo, "oci://") {
+		conditions.Delete(vCluster, v1alpha1.ValuesSchemaValidCondition)
+		return
+	}
+
+	schema, err := r.ValuesSchemaGetter.ValuesSchema(ctx, chartRepo, chartName, chartVersion)
+	if err != nil {
+		r.Log.V(1).Info("error retrieving values schema, skipping values validation",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		conditions.Delete(vCluster, v1alpha1.ValuesSchemaValidCondition)
+		return
+	} else if schema == "" {
+		conditions.Delete(vCluster, v1alpha1.ValuesSchemaValidCondition)
+		return
+	}
+
+	parsed, err := vclustervalues.Parse(values)
+	if err != nil {
+		conditions.Delete(vCluster, v1alpha1.ValuesSchemaValidCondition)
+		return
+	}
+
+	violations, err := valuesschema.Validate(schema, parsed)
+	if err != nil {
+		r.Log.V(1).Info("error validating values schema",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		conditions.Delete(vCluster, v1alpha1.ValuesSchemaValidCondition)
+		return
+	} else if len(violations) > 0 {
+		conditions.MarkFalse(vCluster, v1alpha1.ValuesSchemaValidCondition, InvalidValuesReason, v1alpha1.ConditionSeverityWarning, "helm values violate the values schema of chart %s %s: %s", chartName, chartVersion, strings.Join(violations, "; "))
+		return
+	}
+
+	conditions.MarkTrue(vCluster, v1alpha1.ValuesSchemaValidCondition)
+}
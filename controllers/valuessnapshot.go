@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+)
+
+const (
+	// ValuesSnapshotValuesKey is the key of the deployed helm values in the values snapshot Secret
+	ValuesSnapshotValuesKey = "values.yaml"
+	// ValuesSnapshotChartNameKey is the key of the deployed chart name in the values snapshot Secret
+	ValuesSnapshotChartNameKey = "chart.name"
+	// ValuesSnapshotChartRepoKey is the key of the deployed chart repository in the values snapshot Secret
+	ValuesSnapshotChartRepoKey = "chart.repo"
+	// ValuesSnapshotChartVersionKey is the key of the deployed chart version in the values snapshot Secret
+	ValuesSnapshotChartVersionKey = "chart.version"
+
+	// ValuesSnapshotLabel is set on every values snapshot Secret and holds the vcluster name
+	ValuesSnapshotLabel = "vcluster.loft.sh/values-snapshot"
+
+	// maxValuesSnapshotSize keeps the snapshot below the 1MiB size limit of Secrets
+	maxValuesSnapshotSize = 1000 * 1024
+
+	// redactedSnapshotValue replaces the values taken from valuesFrom Secrets in the values snapshot
+	redactedSnapshotValue = "REDACTED"
+)
+
+func valuesSnapshotName(vCluster *v1alpha1.VCluster) string {
+	if vCluster.Spec.ValuesSnapshot != nil && vCluster.Spec.ValuesSnapshot.Name != "" {
+		return vCluster.Spec.ValuesSnapshot.Name
+	}
+
+	return vCluster.Name + "-values-snapshot"
+}
+
+// ownsValuesSnapshot returns true if the Secret was created as values snapshot of the VCluster
+func ownsValuesSnapshot(vCluster *v1alpha1.VCluster, secret *corev1.Secret) bool {
+	return secret.Labels[ValuesSnapshotLabel] == vCluster.Name || metav1.IsControlledBy(secret, vCluster)
+}
+
+// storeValuesSnapshot stores the deployed values and chart coordinates in a Secret. The values may contain
+// credentials, which is why they are stored in a Secret instead of a ConfigMap. Values taken from
+// valuesFrom Secrets are redacted nevertheless. The Secret has no owner reference on purpose, as it is
+// used to recreate a lost VCluster.
+func (r *VClusterReconciler) storeValuesSnapshot(ctx context.Context, vCluster *v1alpha1.VCluster, chartName, chartRepo, chartVersion, values string) error {
+	if vCluster.Spec.ValuesSnapshot == nil || !vCluster.Spec.ValuesSnapshot.Enabled {
+		return nil
+	}
+
+	values, err := r.redactSecretValues(ctx, vCluster, values)
+	if err != nil {
+		return fmt.Errorf("redact values snapshot: %w", err)
+	}
+	if len(values) > maxValuesSnapshotSize {
+		return fmt.Errorf("values with %d bytes exceed the maximum snapshot size of %d bytes", len(values), maxValuesSnapshotSize)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      valuesSnapshotName(vCluster),
+			Namespace: vCluster.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrPatch(ctx, r.Client, secret, func() error {
+		if secret.ResourceVersion != "" && !ownsValuesSnapshot(vCluster, secret) {
+			return fmt.Errorf("secret %s/%s exists and is no values snapshot of the vcluster", secret.Namespace, secret.Name)
+		}
+
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[ValuesSnapshotLabel] = vCluster.Name
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			ValuesSnapshotValuesKey:       []byte(values),
+			ValuesSnapshotChartNameKey:    []byte(chartName),
+			ValuesSnapshotChartRepoKey:    []byte(chartRepo),
+			ValuesSnapshotChartVersionKey: []byte(chartVersion),
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("store values snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// redactSecretValues replaces every value in the helm values that is also set by one of the
+// valuesFrom Secrets, so the snapshot doesn't copy their credentials. Values of ConfigMaps are
+// kept as they are.
+func (r *VClusterReconciler) redactSecretValues(ctx context.Context, vCluster *v1alpha1.VCluster, values string) (string, error) {
+	if vCluster.Spec.HelmRelease == nil {
+		return values, nil
+	}
+
+	for _, source := range vCluster.Spec.HelmRelease.ValuesFrom {
+		if source.Kind != "Secret" {
+			continue
+		}
+
+		sourceValues, err := r.getValuesSource(ctx, vCluster.Namespace, source)
+		if err != nil {
+			return "", err
+		}
+
+		parsed, err := vclustervalues.Parse(sourceValues)
+		if err != nil {
+			return "", fmt.Errorf("%s %s: %w", source.Kind, source.Name, err)
+		}
+
+		values, err = vclustervalues.Merge(values, redactValues(parsed))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return values, nil
+}
+
+// redactValues returns a copy of the values with every value that is not a map replaced
+func redactValues(values map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			redacted[key] = redactValues(nested)
+			continue
+		}
+
+		redacted[key] = redactedSnapshotValue
+	}
+
+	return redacted
+}
@@ -18,19 +18,29 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"os"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -39,14 +49,21 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
 	clusterv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/constants"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/annotations"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/kubeconfighelper"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/patch"
@@ -94,22 +111,202 @@ type VClusterReconciler struct {
 	Scheme             *runtime.Scheme
 	ClientConfigGetter ClientConfigGetter
 	HTTPClientGetter   HTTPClientGetter
-	clusterKindExists  bool
+	// ChartMetadataGetter is used to check the kubernetes version against repository charts, optional.
+	// The version of local charts is always checked against their archive.
+	ChartMetadataGetter ChartMetadataGetter
+	// ValuesSchemaGetter is used to validate the helm values against the values schema of the chart, optional
+	ValuesSchemaGetter ValuesSchemaGetter
+	// HostClient is used to retrieve the logs of failed helm hooks and to discover the pod CIDR
+	// of the default network policies, optional
+	HostClient kubernetes.Interface
+
+	// MaxValuesSize is the maximum size in bytes of the helm values, zero means no limit
+	MaxValuesSize int
+
+	// AllowedChartRepos restricts the chart repositories a VCluster may use, empty allows all
+	AllowedChartRepos []string
+
+	// AllowedCAConfigMapNamespaces are the namespaces besides their own that VClusters may publish
+	// their CA ConfigMap to, empty restricts VClusters to their own namespace
+	AllowedCAConfigMapNamespaces []string
+
+	// AllowedHelmBinaries are the absolute paths of the helm binaries VClusters may select via
+	// HelmBinaryAnnotation, empty restricts VClusters to the default helm binary
+	AllowedHelmBinaries []string
+
+	// ChartChannelsConfigMap is the ConfigMap that maps chart version channels to versions
+	ChartChannelsConfigMap types.NamespacedName
+
+	// StalledReconcileTimeout is the time after which a VCluster that could not be reconciled is
+	// marked as stalled, zero disables the detection
+	StalledReconcileTimeout time.Duration
+
+	// ReconcileStaleThreshold is the time after which the health check fails if no VCluster
+	// was reconciled successfully, zero disables the check
+	ReconcileStaleThreshold time.Duration
+
+	// ChartCacheDir is the directory of cached chart tgz files, defaults to the working directory
+	ChartCacheDir string
+
+	// WarnOrphanedReleases logs vcluster helm releases that have no VCluster
+	WarnOrphanedReleases bool
+
+	// CheckControlPlaneWorkloads requires all statefulsets and deployments of the vcluster release
+	// to have their replicas ready before the VCluster is marked ready
+	CheckControlPlaneWorkloads bool
+
+	// DriftDetectionInterval is the interval in which the values of deployed helm releases are
+	// compared to the desired values, zero disables the drift detection
+	DriftDetectionInterval time.Duration
+
+	// RequeueJitter randomizes the requeue interval of ready VClusters by up to this fraction in
+	// either direction, zero disables the jitter
+	RequeueJitter float64
+
+	// Finalizer overrides the name of the cleanup finalizer, defaults to CleanupFinalizer
+	Finalizer string
+
+	// CleanupHooks are called in order when a VCluster is deleted, before the finalizer is removed
+	CleanupHooks []CleanupHook
+
+	// Recorder is used to emit events for the VCluster, optional
+	Recorder record.EventRecorder
+
+	clusterKindExists       bool
+	networkPolicyKindExists bool
+
+	deployFailuresMutex sync.Mutex
+	deployFailures      map[types.NamespacedName]int
+
+	stalledMutex sync.Mutex
+	stalled      map[types.NamespacedName]time.Time
+
+	lastReconciledMutex sync.Mutex
+	lastReconciled      map[types.NamespacedName]time.Time
+
+	helmLocksMutex sync.Mutex
+	helmLocks      map[types.NamespacedName]*sync.Mutex
+
+	helmBinariesMutex sync.Mutex
+	helmBinaries      map[string]bool
+
+	driftChecksMutex sync.Mutex
+	driftChecks      map[types.NamespacedName]time.Time
+
+	orphanedReleasesMutex sync.Mutex
+	orphanedReleases      map[string]map[string]bool
 }
 
 type Credentials struct {
 	ClientCert []byte
 	ClientKey  []byte
+	Token      string
 }
 
 const (
 	// A finalizer that is added to the VCluster CR to ensure that helm delete is executed.
 	CleanupFinalizer = "vcluster.loft.sh/cleanup"
 
+	// HelmWaitTimeoutAnnotation makes the controller wait for the helm release to become ready
+	// for the given duration (e.g. "5m") before marking the chart as deployed.
+	HelmWaitTimeoutAnnotation = "vcluster.loft.sh/helm-wait-timeout"
+
+	// DryRunAnnotation makes the controller only run the helm upgrade in dry-run mode and report
+	// the result as an event instead of changing the virtual cluster.
+	DryRunAnnotation = "vcluster.loft.sh/dry-run"
+
+	// RollbackOnFailureAnnotation makes the controller roll back the helm release to the last
+	// deployed revision if an upgrade fails.
+	RollbackOnFailureAnnotation = "vcluster.loft.sh/rollback-on-failure"
+
+	// ReleaseGenerationLabel is added to the helm release secret and holds the VCluster generation
+	// that was deployed.
+	ReleaseGenerationLabel = "vcluster.loft.sh/generation"
+
+	// AdoptAnnotation makes the controller adopt an existing helm release of the same name instead of
+	// upgrading it, as long as it is of the chart and version of the spec and the VCluster results
+	// in no helm values.
+	AdoptAnnotation = "vcluster.loft.sh/adopt"
+
+	// ForceDeleteAnnotation makes the controller remove the finalizer of a deleted VCluster after
+	// ForceDeleteAttempts failed helm deletes, which might orphan the helm release.
+	ForceDeleteAnnotation = "vcluster.loft.sh/force-delete"
+
+	// ForceDeleteAttempts is the number of failed helm deletes after which a VCluster with the
+	// ForceDeleteAnnotation is removed anyway.
+	ForceDeleteAttempts = 5
+
+	// HelmBinaryAnnotation selects the helm binary (e.g. "/usr/local/bin/helm-3.14") used to install
+	// and upgrade the helm release of a VCluster instead of helm.CommandPath. The binary has to be
+	// one of the allowed helm binaries of the controller.
+	HelmBinaryAnnotation = "vcluster.loft.sh/helm-binary"
+
+	// BlockMoveAnnotation is set on VClusters with a deployed helm release and makes clusterctl move
+	// wait until it is removed, which happens once the controller sees the VCluster paused.
+	BlockMoveAnnotation = "clusterctl.cluster.x-k8s.io/block-move"
+
+	// DeleteForMoveAnnotation is set by clusterctl move on the objects it deletes from the source
+	// cluster, helm operations are skipped for these VClusters.
+	DeleteForMoveAnnotation = "clusterctl.cluster.x-k8s.io/delete-for-move"
+
+	// CorrectDriftAnnotation makes the controller upgrade the helm release again when drift
+	// detection finds that its values differ from the desired values.
+	CorrectDriftAnnotation = "vcluster.loft.sh/correct-drift"
+
+	// LoadBalancerWaitTimeoutAnnotation sets the time (e.g. "30s") a reconcile waits for the load balancer
+	// of the vcluster service to get an ingress, the reconcile is retried afterwards.
+	LoadBalancerWaitTimeoutAnnotation = "vcluster.loft.sh/load-balancer-wait-timeout"
+
+	// DefaultLoadBalancerWaitTimeout is the time a reconcile waits for the load balancer ingress by default.
+	DefaultLoadBalancerWaitTimeout = time.Second * 10
+
 	DefaultControlPlanePort = 443
 
+	// DefaultReadyzPath is the default path of the control plane readiness endpoint.
+	DefaultReadyzPath = "/readyz"
+
+	// MaxReadyzHistory is the number of readiness check results kept in the VCluster status
+	MaxReadyzHistory = 5
+
 	// KubeconfigDataName is the key used to store a Kubeconfig in the secret's data field.
 	KubeconfigDataName = "value"
+
+	// DeployBackoffBase is the requeue delay after the first failed helm deploy, it doubles with every consecutive failure.
+	DeployBackoffBase = time.Second * 5
+
+	// DeployBackoffMax is the maximum requeue delay after failed helm deploys.
+	DeployBackoffMax = time.Minute * 5
+
+	// PodTerminationTimeout is the maximum time to wait for the vcluster pods to terminate before the
+	// persistent volume claims are deleted.
+	PodTerminationTimeout = time.Minute * 5
+
+	// ValuesTooLargeReason is used when the helm values exceed the configured maximum size.
+	ValuesTooLargeReason = "ValuesTooLarge"
+
+	// WaitingForLoadBalancerReason is used while the load balancer of the vcluster service has no ingress.
+	WaitingForLoadBalancerReason = "WaitingForLoadBalancer"
+
+	// WaitingForCertsReason is used while the vcluster has not created its kubeconfig and certs secrets yet.
+	WaitingForCertsReason = "WaitingForCerts"
+
+	// ControlPlaneNotLiveReason is used when the startup probe of the control plane fails.
+	ControlPlaneNotLiveReason = "ControlPlaneNotLive"
+
+	// RepoNotAllowedReason is used when the chart repository is not in the list of allowed repositories.
+	RepoNotAllowedReason = "RepoNotAllowed"
+
+	// DryRunSucceededReason is used for the event of a successful helm dry-run.
+	DryRunSucceededReason = "DryRunSucceeded"
+
+	// DryRunFailedReason is used for the event of a failed helm dry-run.
+	DryRunFailedReason = "DryRunFailed"
+
+	// ValuesSnapshotFailedReason is used for the event of a values snapshot that could not be stored.
+	ValuesSnapshotFailedReason = "ValuesSnapshotFailed"
+
+	// PausedReason is used in the status when the helm operations are paused by the spec.
+	PausedReason = "Paused"
 )
 
 func (r *VClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -123,32 +320,35 @@ func (r *VClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 			return ctrl.Result{}, err
 		}
 
+		r.forgetReconciled(req.NamespacedName)
 		return ctrl.Result{}, nil
 	}
 
-	// is deleting?
-	if vCluster.DeletionTimestamp != nil {
-		// check if namespace is deleting
-		namespace := &corev1.Namespace{}
-		err := r.Client.Get(ctx, types.NamespacedName{Name: req.Namespace}, namespace)
-		if err != nil {
-			return ctrl.Result{}, nil
-		} else if namespace.DeletionTimestamp != nil {
-			return ctrl.Result{}, RemoveFinalizer(ctx, r.Client, vCluster, CleanupFinalizer)
-		}
-
-		err = r.deleteHelmChart(ctx, req.Namespace, req.Name)
-		if err != nil {
-			return ctrl.Result{}, err
+	// is clusterctl moving the vcluster to another management cluster?
+	if isMoving(vCluster) {
+		r.Log.V(1).Info("vcluster is moved, skipping helm operations", "namespace", vCluster.Namespace, "name", vCluster.Name)
+		r.forgetReconciled(req.NamespacedName)
+		if vCluster.DeletionTimestamp != nil {
+			r.forgetDeleted(vCluster)
+			return ctrl.Result{}, r.removeFinalizer(ctx, vCluster)
 		}
+		return ctrl.Result{}, nil
+	}
 
-		// delete the persistent volume claim
-		err = r.Client.Delete(ctx, &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-" + vCluster.Name + "-0", Namespace: req.Namespace}})
-		if err != nil && !kerrors.IsNotFound(err) {
-			return ctrl.Result{}, err
-		}
+	// is the vcluster or its owner Cluster paused?
+	paused, err := r.isPaused(ctx, vCluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if paused {
+		r.Log.V(1).Info("reconciliation is paused", "namespace", vCluster.Namespace, "name", vCluster.Name)
+		r.forgetReconciled(req.NamespacedName)
+		return ctrl.Result{}, r.unblockMove(ctx, vCluster)
+	}
 
-		return ctrl.Result{}, RemoveFinalizer(ctx, r.Client, vCluster, CleanupFinalizer)
+	// is deleting?
+	if vCluster.DeletionTimestamp != nil {
+		r.forgetReconciled(req.NamespacedName)
+		return r.reconcileDelete(ctx, vCluster)
 	}
 
 	// is there an owner Cluster CR set by CAPI cluster controller?
@@ -170,7 +370,7 @@ func (r *VClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	}
 
 	// ensure finalizer
-	err = EnsureFinalizer(ctx, r.Client, vCluster, CleanupFinalizer)
+	err = EnsureFinalizer(ctx, r.Client, vCluster, r.finalizer())
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -180,31 +380,126 @@ func (r *VClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-
 	defer func() {
 		// Always reconcile the Status.Phase field.
 		r.reconcilePhase(vCluster)
 
 		// Always attempt to Patch the Cluster object and status after each reconciliation.
-		// Patch ObservedGeneration only if the reconciliation completed successfully
+		// Patch ObservedGeneration only if the reconciliation completed successfully and the chart
+		// was deployed, otherwise the generation is not reconciled yet.
+		converged := reterr == nil && !conditions.IsFalse(vCluster, v1alpha1.HelmChartDeployedCondition) && !isDryRun(vCluster)
+		r.reconcileStalled(vCluster, converged)
+		if converged {
+			vCluster.Status.LastReconcileTime = metav1.Now()
+		}
+
+		// a paused vcluster keeps the generation it was last deployed with
 		patchOpts := []patch.Option{}
-		if reterr == nil {
+		if converged && !vCluster.Spec.Paused {
 			patchOpts = append(patchOpts, patch.WithStatusObservedGeneration{})
 		}
 		if err := patchCluster(ctx, patchHelper, vCluster, patchOpts...); err != nil {
 			reterr = utilerrors.NewAggregate([]error{reterr, err})
+		} else if converged {
+			r.markReconciled(req.NamespacedName, time.Now())
 		}
 	}()
 
+	// make sure the values are not too large to be stored
+	err = r.validateValuesSize(vCluster)
+	if err != nil {
+		r.Log.Info("helm values are too large",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		conditions.MarkFalse(vCluster, v1alpha1.HelmChartDeployedCondition, ValuesTooLargeReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, nil
+	}
+
+	// make sure the chart repo is allowed
+	err = r.validateChartRepo(vCluster)
+	if err != nil {
+		r.Log.Info("chart repository is not allowed",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		conditions.MarkFalse(vCluster, v1alpha1.HelmChartDeployedCondition, RepoNotAllowedReason, v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{}, nil
+	}
+
+	// only run the helm upgrade in dry-run mode without changing anything
+	if isDryRun(vCluster) {
+		r.dryRun(ctx, vCluster)
+		return ctrl.Result{}, nil
+	}
+
+	// ensure the network policies isolating the vcluster namespace
+	err = r.reconcileNetworkPolicies(ctx, vCluster)
+	if err != nil {
+		r.Log.Error(err, "error during network policy reconcile",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
+		conditions.MarkFalse(vCluster, v1alpha1.NetworkPolicyReadyCondition, "NetworkPolicyFailed", v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+	}
+	if vCluster.Spec.NetworkPolicy != nil && vCluster.Spec.NetworkPolicy.Enabled {
+		conditions.MarkTrue(vCluster, v1alpha1.NetworkPolicyReadyCondition)
+	} else {
+		conditions.Delete(vCluster, v1alpha1.NetworkPolicyReadyCondition)
+	}
+
+	// ensure the limit range of the vcluster namespace
+	err = r.reconcileLimitRange(ctx, vCluster)
+	if err != nil {
+		r.Log.Error(err, "error during limit range reconcile",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
+		conditions.MarkFalse(vCluster, v1alpha1.LimitRangeReadyCondition, "LimitRangeFailed", v1alpha1.ConditionSeverityError, "%v", err)
+		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+	}
+	if vCluster.Spec.LimitRange != nil {
+		conditions.MarkTrue(vCluster, v1alpha1.LimitRangeReadyCondition)
+	} else {
+		conditions.Delete(vCluster, v1alpha1.LimitRangeReadyCondition)
+	}
+
+	// warn about values that don't belong to the chart distro
+	r.validateDistroValues(vCluster)
+
 	// check if we have to redeploy
-	err = r.redeployIfNeeded(ctx, vCluster)
+	err = r.redeployIfNeeded(ctx, vCluster, false)
 	if err != nil {
 		r.Log.Error(err, "error during virtual cluster deploy",
 			"namespace", vCluster.Namespace,
 			"name", vCluster.Name,
 		)
 		conditions.MarkFalse(vCluster, v1alpha1.HelmChartDeployedCondition, "HelmDeployFailed", v1alpha1.ConditionSeverityError, "%v", err)
-		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+
+		// we don't return the error here as the controller would otherwise ignore the backoff
+		return ctrl.Result{RequeueAfter: r.deployFailed(req.NamespacedName)}, nil
+	}
+	r.resetDeployBackoff(req.NamespacedName)
+	blockMove(vCluster)
+
+	err = r.reconcileHelmHooks(ctx, vCluster)
+	if err != nil {
+		r.Log.Info("error checking helm hooks",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+	}
+
+	err = r.warnOrphanedReleases(ctx, vCluster.Namespace)
+	if err != nil {
+		r.Log.Info("error checking for orphaned helm releases",
+			"namespace", vCluster.Namespace,
+			"err", err,
+		)
 	}
 
 	// check if vcluster is initialized and sync the kubeconfig Secret
@@ -215,7 +510,31 @@ func (r *VClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 			"name", vCluster.Name,
 			"err", err,
 		)
-		conditions.MarkFalse(vCluster, v1alpha1.KubeconfigReadyCondition, "CheckFailed", v1alpha1.ConditionSeverityWarning, "%v", err)
+		if errors.Is(err, ErrLoadBalancerPending) {
+			conditions.MarkFalse(vCluster, v1alpha1.KubeconfigReadyCondition, WaitingForLoadBalancerReason, v1alpha1.ConditionSeverityInfo, "%v", err)
+		} else if errors.Is(err, ErrCertsPending) {
+			conditions.MarkFalse(vCluster, v1alpha1.KubeconfigReadyCondition, WaitingForCertsReason, v1alpha1.ConditionSeverityInfo, "%v", err)
+		} else {
+			conditions.MarkFalse(vCluster, v1alpha1.KubeconfigReadyCondition, "CheckFailed", v1alpha1.ConditionSeverityWarning, "%v", err)
+		}
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	// publish the vcluster ca if configured
+	err = r.syncCAConfigMap(ctx, vCluster)
+	if errors.Is(err, ErrCertsPending) {
+		r.Log.V(1).Info("waiting for vcluster ca",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	} else if err != nil {
+		r.Log.Info("error publishing vcluster ca",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
 		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 	}
 
@@ -225,7 +544,84 @@ func (r *VClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 	}
 
-	return ctrl.Result{RequeueAfter: time.Minute}, nil
+	vCluster.Status.Ready, err = r.checkControlPlaneWorkloads(ctx, vCluster)
+	if err != nil || !vCluster.Status.Ready {
+		r.Log.V(1).Info("control plane workloads are not ready", "err", err)
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	return ctrl.Result{RequeueAfter: r.requeueInterval()}, nil
+}
+
+// requeueInterval returns the requeue interval of a ready VCluster, jittered by RequeueJitter
+// so that VClusters created together don't keep reconciling at the same time
+func (r *VClusterReconciler) requeueInterval() time.Duration {
+	if r.RequeueJitter <= 0 || r.RequeueJitter >= 1 {
+		return time.Minute
+	}
+
+	// wait.Jitter only adds to the duration, so start at the lower bound of the band
+	lower := time.Duration(float64(time.Minute) * (1 - r.RequeueJitter))
+	return wait.Jitter(lower, 2*r.RequeueJitter/(1-r.RequeueJitter))
+}
+
+func (r *VClusterReconciler) isPaused(ctx context.Context, vCluster *v1alpha1.VCluster) (bool, error) {
+	if annotations.HasPaused(vCluster) {
+		return true, nil
+	}
+
+	// the owner Cluster can only be looked up when installed via CAPI
+	if !r.clusterKindExists {
+		return false, nil
+	}
+
+	cluster, err := GetOwnerCluster(ctx, r.Client, vCluster)
+	if err != nil {
+		// CAPI might already have removed the owner Cluster or its CRD during teardown, which
+		// must not block the cleanup of the vcluster
+		if vCluster.DeletionTimestamp == nil {
+			return false, err
+		}
+
+		r.Log.Info("cannot get owner cluster of deleted vcluster, continuing cleanup",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		return false, nil
+	}
+
+	return annotations.IsPaused(cluster, vCluster), nil
+}
+
+// deployFailed records a failed helm deploy and returns the capped exponential backoff
+// to wait before the next attempt.
+func (r *VClusterReconciler) deployFailed(name types.NamespacedName) time.Duration {
+	r.deployFailuresMutex.Lock()
+	defer r.deployFailuresMutex.Unlock()
+
+	if r.deployFailures == nil {
+		r.deployFailures = map[types.NamespacedName]int{}
+	}
+	r.deployFailures[name]++
+
+	backoff := DeployBackoffBase
+	for i := 1; i < r.deployFailures[name]; i++ {
+		backoff *= 2
+		if backoff >= DeployBackoffMax {
+			return DeployBackoffMax
+		}
+	}
+
+	return backoff
+}
+
+// resetDeployBackoff resets the backoff of failed helm deploys.
+func (r *VClusterReconciler) resetDeployBackoff(name types.NamespacedName) {
+	r.deployFailuresMutex.Lock()
+	defer r.deployFailuresMutex.Unlock()
+
+	delete(r.deployFailures, name)
 }
 
 func (r *VClusterReconciler) reconcilePhase(vCluster *v1alpha1.VCluster) {
@@ -245,15 +641,258 @@ func (r *VClusterReconciler) reconcilePhase(vCluster *v1alpha1.VCluster) {
 			vCluster.Status.Phase = v1alpha1.VirtualClusterFailed
 			vCluster.Status.Reason = c.Reason
 			vCluster.Status.Message = c.Message
+			return
+		}
+	}
+
+	if vCluster.Spec.Paused {
+		vCluster.Status.Reason = PausedReason
+		vCluster.Status.Message = "helm operations are paused by the spec"
+	}
+}
+
+func (r *VClusterReconciler) validateValuesSize(vCluster *v1alpha1.VCluster) error {
+	if r.MaxValuesSize <= 0 || vCluster.Spec.HelmRelease == nil {
+		return nil
+	}
+
+	return checkValuesSize(vCluster.Spec.HelmRelease.Values, r.MaxValuesSize)
+}
+
+// checkValuesSize returns an error if the values exceed the given maximum size in bytes, a maximum
+// of 0 disables the check
+func checkValuesSize(values string, maxSize int) error {
+	if maxSize <= 0 || len(values) <= maxSize {
+		return nil
+	}
+
+	return fmt.Errorf("the helm values are %d bytes, which exceeds the maximum of %d bytes. Please move the values into a referenced Secret or ConfigMap", len(values), maxSize)
+}
+
+func (r *VClusterReconciler) validateChartRepo(vCluster *v1alpha1.VCluster) error {
+	if len(r.AllowedChartRepos) == 0 {
+		return nil
+	}
+
+	chartRepo := getChartRepo(vCluster)
+	for _, allowed := range r.AllowedChartRepos {
+		if chartRepoAllowed(chartRepo, allowed) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("chart repository %s is not allowed, allowed repositories are: %s", chartRepo, strings.Join(r.AllowedChartRepos, ", "))
+}
+
+// chartRepoAllowed returns true if the chart repo has the same scheme and host as the allowed repo
+// and its path is the allowed path or below it
+func chartRepoAllowed(chartRepo, allowed string) bool {
+	chartRepoURL, err := url.Parse(chartRepo)
+	if err != nil || chartRepoURL.Host == "" {
+		return false
+	}
+	allowedURL, err := url.Parse(allowed)
+	if err != nil || allowedURL.Host == "" {
+		return false
+	}
+	if !strings.EqualFold(chartRepoURL.Scheme, allowedURL.Scheme) || !strings.EqualFold(chartRepoURL.Host, allowedURL.Host) {
+		return false
+	}
+
+	chartRepoPath := strings.TrimSuffix(chartRepoURL.Path, "/")
+	allowedPath := strings.TrimSuffix(allowedURL.Path, "/")
+	return chartRepoPath == allowedPath || strings.HasPrefix(chartRepoPath, allowedPath+"/")
+}
+
+func getChartRepo(vCluster *v1alpha1.VCluster) string {
+	var chartRepo string
+	if vCluster.Spec.HelmRelease != nil {
+		chartRepo = vCluster.Spec.HelmRelease.Chart.Repo
+	}
+	if chartRepo == "" {
+		chartRepo = constants.DefaultVClusterRepo
+	}
+
+	return chartRepo
+}
+
+func kubeconfigSecretName(vCluster *v1alpha1.VCluster) string {
+	if vCluster.Spec.KubeconfigSecretName != "" {
+		return vCluster.Spec.KubeconfigSecretName
+	}
+
+	return fmt.Sprintf("%s-kubeconfig", vCluster.Name)
+}
+
+func getChartName(vCluster *v1alpha1.VCluster) string {
+	var chartName string
+	if vCluster.Spec.HelmRelease != nil {
+		chartName = vCluster.Spec.HelmRelease.Chart.Name
+	}
+	if chartName == "" {
+		chartName = constants.DefaultVClusterChartName
+	}
+
+	return chartName
+}
+
+// getChartVersion returns the chart version to deploy without a leading v, the resolved channel
+// version takes precedence over the spec
+func getChartVersion(vCluster *v1alpha1.VCluster, resolvedVersion string) string {
+	chartVersion := vCluster.Spec.HelmRelease.Chart.Version
+	if resolvedVersion != "" {
+		chartVersion = resolvedVersion
+	}
+
+	if len(chartVersion) > 0 && chartVersion[0] == 'v' {
+		chartVersion = chartVersion[1:]
+	}
+
+	return chartVersion
+}
+
+// releaseMetadata returns the description and labels of the helm release that record which
+// VCluster generation and Cluster triggered the deploy
+func releaseMetadata(vCluster *v1alpha1.VCluster) (string, map[string]string) {
+	description := fmt.Sprintf("VCluster %s/%s generation %d", vCluster.Namespace, vCluster.Name, vCluster.Generation)
+	labels := map[string]string{
+		ReleaseGenerationLabel: strconv.FormatInt(vCluster.Generation, 10),
+	}
+	for _, owner := range vCluster.OwnerReferences {
+		if owner.Kind == "Cluster" {
+			description += " of Cluster " + owner.Name
+			labels[clusterv1beta1.ClusterNameLabel] = owner.Name
 			break
 		}
 	}
-}
 
-func (r *VClusterReconciler) redeployIfNeeded(_ context.Context, vCluster *v1alpha1.VCluster) error {
+	return description, labels
+}
+
+func isDryRun(vCluster *v1alpha1.VCluster) bool {
+	return vCluster.Annotations[DryRunAnnotation] == "true"
+}
+
+// dryRun runs the helm upgrade in dry-run mode and reports the result as an event
+func (r *VClusterReconciler) dryRun(ctx context.Context, vCluster *v1alpha1.VCluster) {
+	err := r.redeployIfNeeded(ctx, vCluster, true)
+	if err != nil {
+		r.Log.Info("helm dry-run failed",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, DryRunFailedReason, "helm dry-run failed: %v", err)
+		}
+		return
+	}
+
+	r.Log.Info("helm dry-run succeeded",
+		"namespace", vCluster.Namespace,
+		"name", vCluster.Name,
+	)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(vCluster, corev1.EventTypeNormal, DryRunSucceededReason, "helm dry-run of chart %s version %s succeeded", getChartName(vCluster), vCluster.Spec.HelmRelease.Chart.Version)
+	}
+}
+
+// desiredValues returns the helm values of the VCluster merged with the values that are generated
+// from its spec. The drift detection compares the deployed release to them, so they must not
+// contain values that are only passed to a single install or upgrade.
+func (r *VClusterReconciler) desiredValues(ctx context.Context, vCluster *v1alpha1.VCluster, chartName, chartVersion string) (string, error) {
+	var err error
+	var values string
+	if vCluster.Spec.HelmRelease != nil {
+		values = vCluster.Spec.HelmRelease.Values
+	}
+
+	// add the values of the referenced secrets and config maps
+	values, err = r.mergeValuesFrom(ctx, vCluster, values)
+	if err != nil {
+		return "", err
+	}
+
+	// add the proxy configuration
+	values, err = mergeProxyValues(vCluster, chartName, chartVersion, values)
+	if err != nil {
+		return "", err
+	}
+
+	// add the topology spread constraints
+	values, err = mergeTopologySpreadValues(vCluster, chartName, chartVersion, values)
+	if err != nil {
+		return "", err
+	}
+
+	// add the audit log configuration
+	values, err = mergeAuditLogValues(vCluster, chartName, chartVersion, values)
+	if err != nil {
+		return "", err
+	}
+
+	// add the service account
+	values, err = mergeServiceAccountValues(vCluster, chartName, chartVersion, values)
+	if err != nil {
+		return "", err
+	}
+
+	// add the storage of the control plane
+	values, err = mergeStorageValues(vCluster, chartName, chartVersion, values)
+	if err != nil {
+		return "", err
+	}
+
+	return values, nil
+}
+
+func (r *VClusterReconciler) redeployIfNeeded(ctx context.Context, vCluster *v1alpha1.VCluster, dryRun bool) error {
+	// resolve the chart version channel, this is done on every reconcile to pick up channel changes
+	resolvedVersion, err := r.resolveChartChannel(ctx, vCluster)
+	if err != nil {
+		return err
+	}
+
+	// keep the deployed chart and its conditions as they are while paused
+	if !dryRun && vCluster.Spec.Paused {
+		r.Log.V(1).Info("helm operations are paused",
+			"namespace", vCluster.Namespace,
+			"clusterName", vCluster.Name,
+		)
+		conditions.Set(vCluster, &v1alpha1.Condition{
+			Type:    v1alpha1.PausedCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  PausedReason,
+			Message: "helm operations are paused by the spec",
+		})
+		return nil
+	}
+	conditions.Delete(vCluster, v1alpha1.PausedCondition)
+
+	// a changed values source is deployed like a new generation
+	valuesFromHash, err := r.valuesFromHash(ctx, vCluster)
+	if err != nil {
+		return err
+	}
+
 	// upgrade chart
-	if vCluster.Generation == vCluster.Status.ObservedGeneration && conditions.IsTrue(vCluster, v1alpha1.HelmChartDeployedCondition) {
-		return nil
+	if !dryRun && vCluster.Generation == vCluster.Status.ObservedGeneration && conditions.IsTrue(vCluster, v1alpha1.HelmChartDeployedCondition) && resolvedVersion == vCluster.Status.ResolvedChartVersion && valuesFromHash == vCluster.Status.ValuesFromHash {
+		// the release might have been changed outside of the controller
+		drifted, err := r.detectDrift(ctx, vCluster, resolvedVersion)
+		if err != nil {
+			r.Log.Error(err, "error detecting drift of helm release",
+				"namespace", vCluster.Namespace,
+				"name", vCluster.Name,
+			)
+			return nil
+		} else if !drifted || vCluster.Annotations[CorrectDriftAnnotation] != "true" {
+			return nil
+		}
+
+		r.Log.Info("correct drift of helm release",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
 	}
 
 	r.Log.V(1).Info("upgrade virtual cluster helm chart",
@@ -261,82 +900,188 @@ func (r *VClusterReconciler) redeployIfNeeded(_ context.Context, vCluster *v1alp
 		"clusterName", vCluster.Name,
 	)
 
-	var chartRepo string
-	if vCluster.Spec.HelmRelease != nil {
-		chartRepo = vCluster.Spec.HelmRelease.Chart.Repo
+	chartRepo := getChartRepo(vCluster)
+
+	chartName := getChartName(vCluster)
+
+	if vCluster.Spec.HelmRelease == nil || vCluster.Spec.HelmRelease.Chart.Version == "" {
+		return fmt.Errorf("empty value of the .spec.HelmRelease.Version field")
 	}
-	if chartRepo == "" {
-		chartRepo = constants.DefaultVClusterRepo
+	chartVersion := getChartVersion(vCluster, resolvedVersion)
+
+	values, err := r.desiredValues(ctx, vCluster, chartName, chartVersion)
+	if err != nil {
+		return err
 	}
 
-	// chart name
-	var chartName string
-	if vCluster.Spec.HelmRelease != nil {
-		chartName = vCluster.Spec.HelmRelease.Chart.Name
+	// take over a release that was installed outside of the controller
+	if !dryRun {
+		adopted, err := r.adoptHelmRelease(ctx, vCluster, chartName, chartVersion, resolvedVersion, values)
+		if err != nil {
+			return err
+		} else if adopted {
+			return nil
+		}
 	}
-	if chartName == "" {
-		chartName = constants.DefaultVClusterChartName
+
+	// prefer a chart that is pre-loaded into a secret or config map
+	chartPath, cleanup, err := r.chartFromPath(ctx, vCluster)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if chartPath == "" {
+		chartPath = r.cachedChartPath(vCluster, chartName, chartVersion)
 	}
 
-	if vCluster.Spec.HelmRelease == nil || vCluster.Spec.HelmRelease.Chart.Version == "" {
-		return fmt.Errorf("empty value of the .spec.HelmRelease.Version field")
+	// make sure the chart supports the kubernetes version
+	err = r.validateKubernetesVersion(ctx, vCluster, chartRepo, chartName, chartVersion, chartPath, values)
+	if err != nil {
+		return err
 	}
-	// chart version
-	chartVersion := vCluster.Spec.HelmRelease.Chart.Version
 
-	if len(chartVersion) > 0 && chartVersion[0] == 'v' {
-		chartVersion = chartVersion[1:]
+	// warn about values that don't match the values schema of the chart
+	r.validateValuesSchema(ctx, vCluster, chartRepo, chartName, chartVersion, values)
+
+	if !dryRun {
+		// warn about spec settings that won't take effect
+		r.warnServiceAccountConflict(vCluster, chartName, chartVersion, values)
+		r.checkStorageClass(ctx, vCluster, chartName, chartVersion, values)
+
+		err = r.reconcileAuditPolicy(ctx, vCluster)
+		if err != nil {
+			return err
+		}
 	}
 
-	// determine values
-	var values string
-	if vCluster.Spec.HelmRelease != nil || vCluster.Spec.HelmRelease.Values == "" {
-		values = vCluster.Spec.HelmRelease.Values
+	// should we wait for the release?
+	var waitTimeout time.Duration
+	if vCluster.Annotations[HelmWaitTimeoutAnnotation] != "" {
+		waitTimeout, err = time.ParseDuration(vCluster.Annotations[HelmWaitTimeoutAnnotation])
+		if err != nil {
+			return fmt.Errorf("parse annotation %s: %w", HelmWaitTimeoutAnnotation, err)
+		}
 	}
 
 	r.Log.Info("Deploy virtual cluster",
 		"namespace", vCluster.Namespace,
 		"clusterName", vCluster.Name,
-		"values", values,
+		"valuesFromHash", valuesFromHash,
 	)
-	chartPath := "./" + chartName + "-" + chartVersion + ".tgz"
-	_, err := os.Stat(chartPath)
+	unlock := r.lockHelmRelease(types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name})
+	defer unlock()
+
+	helmPath, err := r.helmBinary(ctx, vCluster)
 	if err != nil {
+		return err
+	}
+	description, labels := releaseMetadata(vCluster)
+	operation, start := "upgrade", time.Now()
+	if dryRun {
+		operation = "dry-run"
+	}
+	if chartPath == "" {
+		options := helm.UpgradeOptions{
+			Chart:       chartName,
+			Repo:        chartRepo,
+			Version:     chartVersion,
+			Values:      values,
+			Wait:        waitTimeout > 0,
+			Timeout:     waitTimeout,
+			DryRun:      dryRun,
+			Description: description,
+			Labels:      labels,
+			HelmPath:    helmPath,
+		}
+		err = r.setRepoCredentials(ctx, vCluster, &options)
+		if err != nil {
+			return err
+		}
+
 		// we have to upgrade / install the chart
-		err = r.HelmClient.Upgrade(vCluster.Name, vCluster.Namespace, helm.UpgradeOptions{
-			Chart:   chartName,
-			Repo:    chartRepo,
-			Version: chartVersion,
-			Values:  values,
-		})
+		err = r.HelmClient.Upgrade(ctx, vCluster.Name, vCluster.Namespace, options)
 	} else {
 		// we have to upgrade / install the chart
-		err = r.HelmClient.Upgrade(vCluster.Name, vCluster.Namespace, helm.UpgradeOptions{
-			Path:   chartPath,
-			Values: values,
+		err = r.HelmClient.Upgrade(ctx, vCluster.Name, vCluster.Namespace, helm.UpgradeOptions{
+			Path:        chartPath,
+			Values:      values,
+			Wait:        waitTimeout > 0,
+			Timeout:     waitTimeout,
+			DryRun:      dryRun,
+			Description: description,
+			Labels:      labels,
+			HelmPath:    helmPath,
 		})
 	}
+	observeHelmOperation(operation, start, err)
 	if err != nil {
 		if len(err.Error()) > 512 {
 			err = fmt.Errorf("%v ... ", err.Error()[:512])
 		}
+		if !dryRun {
+			r.rollbackFailedUpgrade(ctx, vCluster, err)
+		}
 
 		return fmt.Errorf("error installing / upgrading vcluster: %w", err)
 	}
+	if dryRun {
+		return nil
+	}
 
+	vCluster.Status.ResolvedChartVersion = resolvedVersion
+	vCluster.Status.ValuesFromHash = valuesFromHash
+	vCluster.Status.LastHelmDeployTime = metav1.Now()
 	conditions.MarkTrue(vCluster, v1alpha1.HelmChartDeployedCondition)
+	conditions.Delete(vCluster, v1alpha1.DriftDetectedCondition)
 	conditions.Delete(vCluster, v1alpha1.KubeconfigReadyCondition)
 
+	// record what was actually deployed, as the chart version might not be pinned
+	r.recordInstalledRelease(ctx, vCluster)
+
+	// store the values to be able to recreate the vcluster, the deploy itself succeeded already
+	err = r.storeValuesSnapshot(ctx, vCluster, chartName, chartRepo, chartVersion, values)
+	if err != nil {
+		r.Log.Error(err, "error storing values snapshot",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+		)
+		if r.Recorder != nil {
+			r.Recorder.Eventf(vCluster, corev1.EventTypeWarning, ValuesSnapshotFailedReason, "error storing values snapshot: %v", err)
+		}
+	}
+
 	return nil
 }
 
+// recordInstalledRelease writes the chart and app version of the deployed helm release into the status
+func (r *VClusterReconciler) recordInstalledRelease(ctx context.Context, vCluster *v1alpha1.VCluster) {
+	if r.HelmSecrets == nil {
+		return
+	}
+
+	release, err := r.HelmSecrets.Get(ctx, vCluster.Name, vCluster.Namespace)
+	if err != nil {
+		r.Log.V(1).Info("error retrieving deployed helm release",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+		return
+	} else if release.Chart == nil || release.Chart.Metadata == nil {
+		return
+	}
+
+	vCluster.Status.InstalledChartVersion = release.Chart.Metadata.Version
+	vCluster.Status.InstalledAppVersion = release.Chart.Metadata.AppVersion
+}
+
 func (r *VClusterReconciler) syncVClusterKubeconfig(ctx context.Context, vCluster *v1alpha1.VCluster) (*rest.Config, error) {
 	credentials, err := GetVClusterCredentials(ctx, r.Client, vCluster)
 	if err != nil {
 		return nil, err
 	}
 
-	restConfig, err := kubeconfighelper.NewVClusterClientConfig(vCluster.Name, vCluster.Namespace, "", credentials.ClientCert, credentials.ClientKey)
+	restConfig, err := kubeconfighelper.NewVClusterClientConfig(vCluster.Name, vCluster.Namespace, credentials.Token, credentials.ClientCert, credentials.ClientKey)
 	if err != nil {
 		return nil, err
 	}
@@ -362,7 +1107,19 @@ func (r *VClusterReconciler) syncVClusterKubeconfig(ctx context.Context, vCluste
 	// that it is set on old CRs, which were missing this field, as well
 	vCluster.Status.Initialized = true
 
-	// write kubeconfig to the vcluster.Name+"-kubeconfig" Secret as expected by CAPI convention
+	// record the kubernetes version of the vcluster, a failure here should not block the reconcile
+	serverVersion, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		r.Log.V(1).Info("error retrieving vcluster kubernetes version",
+			"namespace", vCluster.Namespace,
+			"name", vCluster.Name,
+			"err", err,
+		)
+	} else {
+		vCluster.Status.KubernetesVersion = serverVersion.GitVersion
+	}
+
+	// write kubeconfig to the vcluster.Name+"-kubeconfig" Secret as expected by CAPI convention, unless overridden
 	kubeConfig, err := GetVClusterKubeConfig(ctx, r.Client, vCluster)
 	if err != nil {
 		return nil, fmt.Errorf("can not retrieve kubeconfig: %w", err)
@@ -371,29 +1128,32 @@ func (r *VClusterReconciler) syncVClusterKubeconfig(ctx context.Context, vCluste
 		return nil, fmt.Errorf("unexpected kube config")
 	}
 
-	// If vcluster.spec.controlPlaneEndpoint.Host is not set, try to autodiscover it from
-	// the Service that targets vcluster pods, and write it back into the spec.
+	// If vcluster.spec.controlPlaneEndpoint.Host is not set or was discovered before, try to autodiscover
+	// it from the Service that targets vcluster pods, and write it back into the spec if it changed, e.g.
+	// after a load balancer failover. A manually configured host is never changed.
 	controlPlaneHost := vCluster.Spec.ControlPlaneEndpoint.Host
-	if controlPlaneHost == "" {
+	if controlPlaneHost == "" || controlPlaneHost == vCluster.Status.DiscoveredControlPlaneHost {
 		controlPlaneHost, err = DiscoverHostFromService(ctx, r.Client, vCluster)
 		if err != nil {
 			return nil, err
 		}
-		// write the discovered host back into vCluster CR
+		// write the discovered host back into vCluster CR, the spec is only patched if it changed
 		vCluster.Spec.ControlPlaneEndpoint.Host = controlPlaneHost
 		if vCluster.Spec.ControlPlaneEndpoint.Port == 0 {
 			vCluster.Spec.ControlPlaneEndpoint.Port = DefaultControlPlanePort
 		}
+		vCluster.Status.DiscoveredControlPlaneHost = controlPlaneHost
 	}
 
 	for k := range kubeConfig.Clusters {
+		if vCluster.Spec.KubeconfigServerOverride != "" {
+			kubeConfig.Clusters[k].Server = vCluster.Spec.KubeconfigServerOverride
+			continue
+		}
+
 		host := kubeConfig.Clusters[k].Server
 		if controlPlaneHost != "" {
-			if vCluster.Spec.ControlPlaneEndpoint.Port != 0 {
-				host = fmt.Sprintf("%s:%d", controlPlaneHost, vCluster.Spec.ControlPlaneEndpoint.Port)
-			} else {
-				host = fmt.Sprintf("%s:%d", controlPlaneHost, DefaultControlPlanePort)
-			}
+			host = controlPlaneAddress(controlPlaneHost, vCluster.Spec.ControlPlaneEndpoint.Port)
 		}
 		if !strings.HasPrefix(host, "https://") {
 			host = "https://" + host
@@ -407,7 +1167,7 @@ func (r *VClusterReconciler) syncVClusterKubeconfig(ctx context.Context, vCluste
 
 	kubeSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-kubeconfig", vCluster.Name),
+			Name:      kubeconfigSecretName(vCluster),
 			Namespace: vCluster.Namespace,
 			Labels: map[string]string{
 				clusterv1beta1.ClusterNameLabel: vCluster.Name,
@@ -416,6 +1176,10 @@ func (r *VClusterReconciler) syncVClusterKubeconfig(ctx context.Context, vCluste
 		Type: clusterv1beta1.ClusterSecretType,
 	}
 	_, err = controllerutil.CreateOrPatch(ctx, r.Client, kubeSecret, func() error {
+		// don't overwrite a secret that is managed by someone else
+		if kubeSecret.ResourceVersion != "" && kubeSecret.Labels[clusterv1beta1.ClusterNameLabel] != vCluster.Name {
+			return fmt.Errorf("secret %s already exists and is not managed by this vcluster", kubeSecret.Name)
+		}
 		if kubeSecret.Data == nil {
 			kubeSecret.Data = make(map[string][]byte)
 		}
@@ -430,33 +1194,124 @@ func (r *VClusterReconciler) syncVClusterKubeconfig(ctx context.Context, vCluste
 	return restConfig, nil
 }
 
-func (r *VClusterReconciler) checkReadyz(vCluster *v1alpha1.VCluster, restConfig *rest.Config) (bool, error) {
+func (r *VClusterReconciler) checkReadyz(vCluster *v1alpha1.VCluster, restConfig *rest.Config) (ready bool, err error) {
 	t := time.Now()
+	defer func() {
+		recordReadyzProbe(vCluster, t, ready, err)
+	}()
+
 	transport, err := rest.TransportFor(restConfig)
 	if err != nil {
 		return false, err
 	}
 	client := r.HTTPClientGetter.ClientFor(transport, 10*time.Second)
-	resp, err := client.Get(fmt.Sprintf("https://%s:%d/readyz", vCluster.Spec.ControlPlaneEndpoint.Host, vCluster.Spec.ControlPlaneEndpoint.Port))
-	r.Log.V(1).Info("ready check done", "namespace", vCluster.Namespace, "name", vCluster.Name, "duration", time.Since(t))
+
+	// check if the api server is up before checking if it is ready
+	if vCluster.Spec.StartupProbePath != "" {
+		live, err := r.probeControlPlane(client, vCluster, vCluster.Spec.StartupProbePath)
+		if err != nil {
+			conditions.MarkFalse(vCluster, v1alpha1.ControlPlaneLiveCondition, ControlPlaneNotLiveReason, v1alpha1.ConditionSeverityWarning, "%v", err)
+			return false, err
+		} else if !live {
+			conditions.MarkFalse(vCluster, v1alpha1.ControlPlaneLiveCondition, ControlPlaneNotLiveReason, v1alpha1.ConditionSeverityWarning, "%s is not ok", vCluster.Spec.StartupProbePath)
+			return false, nil
+		}
+
+		conditions.MarkTrue(vCluster, v1alpha1.ControlPlaneLiveCondition)
+	} else {
+		conditions.Delete(vCluster, v1alpha1.ControlPlaneLiveCondition)
+	}
+
+	readyzPath := vCluster.Spec.ReadyzPath
+	if readyzPath == "" {
+		readyzPath = DefaultReadyzPath
+	}
+
+	return r.probeControlPlane(client, vCluster, readyzPath)
+}
+
+// controlPlaneAddress returns the host:port of the control plane endpoint. The host can be an ip, a dns
+// name or already contain a port, in which case the port of the endpoint is ignored.
+func controlPlaneAddress(host string, port int32) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "https://"), "/")
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if port == 0 {
+		port = DefaultControlPlanePort
+	}
+
+	return net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(int(port)))
+}
+
+// probeControlPlane requests the given health endpoint of the control plane and checks the response
+func (r *VClusterReconciler) probeControlPlane(client *http.Client, vCluster *v1alpha1.VCluster, probePath string) (bool, error) {
+	if !strings.HasPrefix(probePath, "/") {
+		probePath = "/" + probePath
+	}
+
+	t := time.Now()
+	resp, err := client.Get("https://" + controlPlaneAddress(vCluster.Spec.ControlPlaneEndpoint.Host, vCluster.Spec.ControlPlaneEndpoint.Port) + probePath)
+	controlPlaneProbeDuration.WithLabelValues(probePath).Observe(time.Since(t).Seconds())
+	r.Log.V(1).Info("health check done", "namespace", vCluster.Namespace, "name", vCluster.Name, "path", probePath, "duration", time.Since(t))
 	if err != nil {
 		return false, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, nil
+	} else if vCluster.Spec.IgnoreReadyzBody || resp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return false, err
 	}
-	if string(body) != "ok" {
+	if strings.TrimSpace(string(body)) != "ok" {
 		return false, nil
 	}
 
 	return true, nil
 }
 
+// recordReadyzProbe adds the readiness check result to the status history and drops the oldest results
+func recordReadyzProbe(vCluster *v1alpha1.VCluster, start time.Time, ready bool, err error) {
+	probe := v1alpha1.VirtualClusterReadyzProbe{
+		Time:    metav1.NewTime(start),
+		Ready:   ready,
+		Latency: metav1.Duration{Duration: time.Since(start).Round(time.Millisecond)},
+	}
+	if err != nil {
+		probe.Error = err.Error()
+	}
+
+	vCluster.Status.ReadyzHistory = append(vCluster.Status.ReadyzHistory, probe)
+	if len(vCluster.Status.ReadyzHistory) > MaxReadyzHistory {
+		vCluster.Status.ReadyzHistory = vCluster.Status.ReadyzHistory[len(vCluster.Status.ReadyzHistory)-MaxReadyzHistory:]
+	}
+}
+
+// ErrLoadBalancerPending is returned if the load balancer of the vcluster service has no ingress yet
+var ErrLoadBalancerPending = errors.New("waiting for load balancer ingress of service")
+
+// ErrCertsPending is returned if the vcluster has not created its kubeconfig or certs secret yet
+var ErrCertsPending = errors.New("waiting for vcluster secret")
+
+// DiscoverHostFromService returns the load balancer ingress of the vcluster service or its cluster
+// dns name if the service is not a load balancer.
 func DiscoverHostFromService(ctx context.Context, client client.Client, vCluster *v1alpha1.VCluster) (string, error) {
+	timeout := DefaultLoadBalancerWaitTimeout
+	if vCluster.Annotations[LoadBalancerWaitTimeoutAnnotation] != "" {
+		var err error
+		timeout, err = time.ParseDuration(vCluster.Annotations[LoadBalancerWaitTimeoutAnnotation])
+		if err != nil {
+			return "", fmt.Errorf("parse annotation %s: %w", LoadBalancerWaitTimeoutAnnotation, err)
+		}
+	}
+
 	host := ""
-	err := wait.PollUntilContextTimeout(ctx, time.Second*2, time.Second*10, true, func(ctx context.Context) (done bool, err error) {
+	err := wait.PollUntilContextTimeout(ctx, min(time.Second*2, timeout), timeout, true, func(ctx context.Context) (done bool, err error) {
 		service := &corev1.Service{}
 		err = client.Get(ctx, types.NamespacedName{Namespace: vCluster.Namespace, Name: vCluster.Name}, service)
 		if err != nil {
@@ -488,7 +1343,9 @@ func DiscoverHostFromService(ctx context.Context, client client.Client, vCluster
 		}
 		return true, nil
 	})
-	if err != nil {
+	if wait.Interrupted(err) {
+		return "", fmt.Errorf("%w %s/%s", ErrLoadBalancerPending, vCluster.Namespace, vCluster.Name)
+	} else if err != nil {
 		return "", fmt.Errorf("can not get vcluster service: %w", err)
 	}
 
@@ -498,13 +1355,46 @@ func DiscoverHostFromService(ctx context.Context, client client.Client, vCluster
 	return host, nil
 }
 
+// GetOwnerCluster returns the Cluster object owning the given VCluster or nil if there is none,
+// the owner Cluster was already deleted or the Cluster kind is not installed anymore.
+func GetOwnerCluster(ctx context.Context, clusterClient client.Client, vCluster *v1alpha1.VCluster) (*clusterv1beta1.Cluster, error) {
+	for _, ref := range vCluster.OwnerReferences {
+		if ref.Kind != "Cluster" {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		if gv.Group != clusterv1beta1.GroupVersion.Group {
+			continue
+		}
+
+		cluster := &clusterv1beta1.Cluster{}
+		err = clusterClient.Get(ctx, types.NamespacedName{Namespace: vCluster.Namespace, Name: ref.Name}, cluster)
+		if err != nil {
+			if kerrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		return cluster, nil
+	}
+
+	return nil, nil
+}
+
 func GetVClusterKubeConfig(ctx context.Context, clusterClient client.Client, vCluster *v1alpha1.VCluster) (*api.Config, error) {
 	// NOTE: The prefix must be kept in sync with https://github.com/loft-sh/vcluster/blob/main/pkg/util/kubeconfig/kubeconfig.go#L29
 	secretName := "vc-" + vCluster.Name
 
 	secret := &corev1.Secret{}
 	err := clusterClient.Get(ctx, types.NamespacedName{Namespace: vCluster.Namespace, Name: secretName}, secret)
-	if err != nil {
+	if kerrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w %s/%s: %w", ErrCertsPending, vCluster.Namespace, secretName, err)
+	} else if err != nil {
 		return nil, err
 	}
 
@@ -537,7 +1427,16 @@ func GetVClusterCredentials(ctx context.Context, clusterClient client.Client, vC
 		}
 	}
 
-	return nil, fmt.Errorf("couldn't parse kube config, because it seems the vcluster kube config is invalid and missing client cert & client key")
+	// some distros only expose a service account token instead of client certs
+	for _, authInfo := range kubeConfig.AuthInfos {
+		if authInfo.Token != "" {
+			return &Credentials{
+				Token: authInfo.Token,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("couldn't parse kube config, because it seems the vcluster kube config is invalid and missing client cert & client key or token")
 }
 
 func (r *VClusterReconciler) deleteHelmChart(ctx context.Context, namespace, name string) error {
@@ -558,7 +1457,81 @@ func (r *VClusterReconciler) deleteHelmChart(ctx context.Context, namespace, nam
 		"namespace", namespace,
 		"name", name,
 	)
-	return r.HelmClient.Delete(name, namespace)
+	unlock := r.lockHelmRelease(types.NamespacedName{Namespace: namespace, Name: name})
+	defer unlock()
+	start := time.Now()
+	err = r.HelmClient.Delete(ctx, name, namespace)
+	observeHelmOperation("delete", start, err)
+	return err
+}
+
+// podsTerminating checks if there are still pods of the vcluster release. After PodTerminationTimeout
+// since the deletion of the VCluster the pods are ignored to not block the deletion forever.
+func (r *VClusterReconciler) podsTerminating(ctx context.Context, vCluster *v1alpha1.VCluster) (bool, error) {
+	if vCluster.DeletionTimestamp != nil && time.Since(vCluster.DeletionTimestamp.Time) > PodTerminationTimeout {
+		return false, nil
+	}
+
+	// the pods of the vcluster and etcd statefulsets have the same labels as their claims
+	selector, err := persistentVolumeClaimSelector(vCluster.Name)
+	if err != nil {
+		return false, err
+	}
+
+	podList := &corev1.PodList{}
+	err = r.Client.List(ctx, podList, client.InNamespace(vCluster.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return false, fmt.Errorf("list pods: %w", err)
+	}
+
+	return len(podList.Items) > 0, nil
+}
+
+// deletePersistentVolumeClaims deletes all persistent volume claims of the vcluster release,
+// which includes the claims of every replica of the vcluster and etcd statefulsets.
+func (r *VClusterReconciler) deletePersistentVolumeClaims(ctx context.Context, vCluster *v1alpha1.VCluster) error {
+	selector, err := persistentVolumeClaimSelector(vCluster.Name)
+	if err != nil {
+		return err
+	}
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	err = r.Client.List(ctx, pvcList, client.InNamespace(vCluster.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return fmt.Errorf("list persistent volume claims: %w", err)
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		// make sure we never delete a claim of another release
+		if pvc.Labels["release"] != vCluster.Name {
+			continue
+		}
+
+		r.Log.V(1).Info("delete vcluster persistent volume claim",
+			"namespace", pvc.Namespace,
+			"name", pvc.Name,
+		)
+		err = r.Client.Delete(ctx, pvc)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("delete persistent volume claim %s: %w", pvc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func persistentVolumeClaimSelector(release string) (labels.Selector, error) {
+	releaseRequirement, err := labels.NewRequirement("release", selection.Equals, []string{release})
+	if err != nil {
+		return nil, err
+	}
+	appRequirement, err := labels.NewRequirement("app", selection.In, []string{"vcluster", "vcluster-etcd"})
+	if err != nil {
+		return nil, err
+	}
+
+	return labels.NewSelector().Add(*releaseRequirement, *appRequirement), nil
 }
 
 func patchCluster(ctx context.Context, patchHelper *patch.Helper, vCluster *v1alpha1.VCluster, options ...patch.Option) error {
@@ -567,6 +1540,8 @@ func patchCluster(ctx context.Context, patchHelper *patch.Helper, vCluster *v1al
 		conditions.WithConditions(
 			v1alpha1.KubeconfigReadyCondition,
 			v1alpha1.ControlPlaneInitializedCondition,
+			v1alpha1.ControlPlaneLiveCondition,
+			v1alpha1.ControlPlaneWorkloadsReadyCondition,
 		),
 	)
 
@@ -578,18 +1553,29 @@ func patchCluster(ctx context.Context, patchHelper *patch.Helper, vCluster *v1al
 			v1alpha1.ReadyCondition,
 			v1alpha1.KubeconfigReadyCondition,
 			v1alpha1.ControlPlaneInitializedCondition,
+			v1alpha1.ControlPlaneLiveCondition,
+			v1alpha1.ControlPlaneWorkloadsReadyCondition,
 			v1alpha1.HelmChartDeployedCondition,
+			v1alpha1.NetworkPolicyReadyCondition,
+			v1alpha1.HelmHooksSucceededCondition,
+			v1alpha1.StalledReconcileCondition,
+			v1alpha1.DistroValuesValidCondition,
+			v1alpha1.LimitRangeReadyCondition,
+			v1alpha1.KubernetesVersionSupportedCondition,
+			v1alpha1.CleanupSucceededCondition,
+			v1alpha1.ValuesSchemaValidCondition,
+			v1alpha1.DriftDetectedCondition,
 		}},
 	)
 	return patchHelper.Patch(ctx, vCluster, options...)
 }
 
-func RemoveFinalizer(ctx context.Context, client client.Client, obj client.Object, finalizer string) error {
+func RemoveFinalizer(ctx context.Context, client client.Client, obj client.Object, finalizer ...string) error {
 	finalizers := obj.GetFinalizers()
 	if len(finalizers) > 0 {
 		newFinalizers := []string{}
 		for _, f := range finalizers {
-			if f == finalizer {
+			if slices.Contains(finalizer, f) {
 				continue
 			}
 			newFinalizers = append(newFinalizers, f)
@@ -627,23 +1613,148 @@ func EnsureFinalizer(ctx context.Context, client client.Client, obj client.Objec
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	var err error
-	r.clusterKindExists, err = kindExists(mgr.GetConfig(), clusterv1beta1.GroupVersion.WithKind("Cluster"))
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	err = r.DiscoverKinds(discoveryClient)
+	if err != nil {
+		return err
+	}
+
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.VCluster{}, ValuesSourceIndex, ValuesSourceIndexValues)
 	if err != nil {
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.VCluster{}).
-		Complete(r)
+	// values sources are only watched by their metadata, so their data is not held in memory
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.VCluster{}, builder.WithPredicates(statusUpdatePredicate(), NotPausedPredicate())).
+		Owns(&corev1.LimitRange{}).
+		WatchesMetadata(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.VClustersForValuesSource), builder.WithPredicates(notHelmReleasePredicate())).
+		WatchesMetadata(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.VClustersForValuesSource))
+	if r.clusterKindExists {
+		// a VCluster paused by its owner Cluster is reconciled again once the Cluster is unpaused
+		controllerBuilder = controllerBuilder.Watches(&clusterv1beta1.Cluster{}, handler.EnqueueRequestsFromMapFunc(r.VClusterForCluster), builder.WithPredicates(ClusterUnpausedPredicate()))
+	}
+	if r.networkPolicyKindExists {
+		controllerBuilder = controllerBuilder.Owns(&networkingv1.NetworkPolicy{})
+	}
+
+	return controllerBuilder.Complete(r)
 }
 
-func kindExists(config *rest.Config, groupVersionKind schema.GroupVersionKind) (bool, error) {
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+// DiscoverKinds checks if the cluster serves the optional kinds the reconciler works with, it is
+// called by SetupWithManager
+func (r *VClusterReconciler) DiscoverKinds(discoveryClient discovery.DiscoveryInterface) error {
+	var err error
+	r.clusterKindExists, err = kindExists(discoveryClient, clusterv1beta1.GroupVersion.WithKind("Cluster"))
 	if err != nil {
-		return false, err
+		return err
+	}
+	r.networkPolicyKindExists, err = kindExists(discoveryClient, networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NotPausedPredicate filters the events of VClusters with the cluster-api paused annotation. The
+// update that pauses a VCluster is still let through, so the reconciler can handle the pause, as
+// well as paused VClusters that still block clusterctl move.
+func NotPausedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return !pausedIdle(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !pausedIdle(e.ObjectNew) || !annotations.HasPaused(e.ObjectOld)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return !pausedIdle(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return !pausedIdle(e.Object)
+		},
+	}
+}
+
+// VClusterForCluster maps a Cluster to the VCluster referenced as its infrastructure
+func (r *VClusterReconciler) VClusterForCluster(_ context.Context, obj client.Object) []reconcile.Request {
+	cluster, ok := obj.(*clusterv1beta1.Cluster)
+	if !ok || cluster.Spec.InfrastructureRef == nil {
+		return nil
+	}
+
+	ref := cluster.Spec.InfrastructureRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil || gv.Group != v1alpha1.GroupVersion.Group || ref.Kind != "VCluster" {
+		return nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cluster.Namespace
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: ref.Name}}}
+}
+
+// ClusterUnpausedPredicate lets the creation of unpaused Clusters and the updates that unpause a
+// Cluster through, the reconciler doesn't need to see any other Cluster event.
+func ClusterUnpausedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			cluster, ok := e.Object.(*clusterv1beta1.Cluster)
+			return ok && !cluster.Spec.Paused
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCluster, ok := e.ObjectOld.(*clusterv1beta1.Cluster)
+			if !ok {
+				return false
+			}
+			newCluster, ok := e.ObjectNew.(*clusterv1beta1.Cluster)
+			return ok && oldCluster.Spec.Paused && !newCluster.Spec.Paused
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// pausedIdle returns true if the object is paused and there is nothing left to do for it
+func pausedIdle(obj client.Object) bool {
+	_, blocksMove := obj.GetAnnotations()[BlockMoveAnnotation]
+	return annotations.HasPaused(obj) && !blocksMove
+}
+
+// statusUpdatePredicate filters updates that only changed the status of the VCluster. Those are
+// caused by the controller itself, e.g. when recording readiness checks, and would otherwise
+// immediately trigger another reconcile.
+func statusUpdatePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldVCluster, ok := e.ObjectOld.(*v1alpha1.VCluster)
+			if !ok {
+				return true
+			}
+			newVCluster, ok := e.ObjectNew.(*v1alpha1.VCluster)
+			if !ok {
+				return true
+			}
+
+			oldMeta, newMeta := oldVCluster.ObjectMeta.DeepCopy(), newVCluster.ObjectMeta.DeepCopy()
+			oldMeta.ResourceVersion, newMeta.ResourceVersion = "", ""
+			oldMeta.ManagedFields, newMeta.ManagedFields = nil, nil
+			return !equality.Semantic.DeepEqual(oldMeta, newMeta) || !equality.Semantic.DeepEqual(oldVCluster.Spec, newVCluster.Spec)
+		},
 	}
+}
 
+func kindExists(discoveryClient discovery.DiscoveryInterface, groupVersionKind schema.GroupVersionKind) (bool, error) {
 	resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersionKind.GroupVersion().String())
 	if err != nil {
 		if kerrors.IsNotFound(err) {
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/constants"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/vclustervalues"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha1-vcluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=vclusters,verbs=create;update,versions=v1alpha1,name=default.vcluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-vcluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=vclusters,verbs=create;update,versions=v1alpha1,name=validation.vcluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+// VClusterWebhook defaults and validates VClusters, so invalid specs are rejected up front instead
+// of only surfacing as conditions during the reconcile
+type VClusterWebhook struct {
+	// MaxValuesSize is the maximum size in bytes of the helm values, 0 disables the limit
+	MaxValuesSize int
+
+	// AllowedCAConfigMapNamespaces are the namespaces besides their own that VClusters may publish
+	// their CA ConfigMap to
+	AllowedCAConfigMapNamespaces []string
+}
+
+var _ webhook.CustomDefaulter = &VClusterWebhook{}
+var _ webhook.CustomValidator = &VClusterWebhook{}
+
+// SetupWebhookWithManager registers the defaulting and validation webhooks of VClusters
+func (w *VClusterWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.VCluster{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default sets the chart name and repo of the helm release to the default vcluster chart
+func (w *VClusterWebhook) Default(_ context.Context, obj runtime.Object) error {
+	vCluster, ok := obj.(*v1alpha1.VCluster)
+	if !ok {
+		return fmt.Errorf("expected a VCluster but got %T", obj)
+	} else if vCluster.Spec.HelmRelease == nil {
+		return nil
+	}
+
+	if vCluster.Spec.HelmRelease.Chart.Name == "" {
+		vCluster.Spec.HelmRelease.Chart.Name = constants.DefaultVClusterChartName
+	}
+	if vCluster.Spec.HelmRelease.Chart.Repo == "" {
+		vCluster.Spec.HelmRelease.Chart.Repo = constants.DefaultVClusterRepo
+	}
+	return nil
+}
+
+// ValidateCreate validates a new VCluster
+func (w *VClusterWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	vCluster, ok := obj.(*v1alpha1.VCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a VCluster but got %T", obj)
+	}
+
+	return nil, w.validateVCluster(vCluster)
+}
+
+// ValidateUpdate validates an updated VCluster, VClusters that are deleted are not validated to not
+// block the removal of their finalizer
+func (w *VClusterWebhook) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	vCluster, ok := newObj.(*v1alpha1.VCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a VCluster but got %T", newObj)
+	} else if !vCluster.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return nil, w.validateVCluster(vCluster)
+}
+
+// ValidateDelete allows all deletions
+func (w *VClusterWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *VClusterWebhook) validateVCluster(vCluster *v1alpha1.VCluster) error {
+	allErrs := field.ErrorList{}
+	if vCluster.Spec.CAConfigMap != nil && vCluster.Spec.CAConfigMap.Enabled {
+		if err := checkCAConfigMapNamespace(vCluster, w.AllowedCAConfigMapNamespaces); err != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "caConfigMap", "namespace"), err.Error()))
+		}
+	}
+	if vCluster.Spec.HelmRelease == nil {
+		return invalidVCluster(vCluster, allErrs)
+	}
+
+	helmRelease := field.NewPath("spec", "helmRelease")
+	version := vCluster.Spec.HelmRelease.Chart.Version
+	versionPath := helmRelease.Child("chart", "version")
+	if version == "" {
+		allErrs = append(allErrs, field.Required(versionPath, "the chart version is required"))
+	} else if strings.HasPrefix(version, ChartChannelPrefix) {
+		if strings.TrimPrefix(version, ChartChannelPrefix) == "" {
+			allErrs = append(allErrs, field.Invalid(versionPath, version, "the chart version channel has no name"))
+		}
+	} else if _, err := semver.NewVersion(strings.TrimPrefix(version, "v")); err != nil {
+		allErrs = append(allErrs, field.Invalid(versionPath, version, fmt.Sprintf("the chart version is no valid semantic version: %v", err)))
+	}
+
+	if vCluster.Spec.HelmRelease.Values != "" {
+		_, err := vclustervalues.Parse(vCluster.Spec.HelmRelease.Values)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(helmRelease.Child("values"), "", fmt.Sprintf("the values are no valid yaml: %v", err)))
+		}
+	}
+	if err := checkValuesSize(vCluster.Spec.HelmRelease.Values, w.MaxValuesSize); err != nil {
+		allErrs = append(allErrs, field.Invalid(helmRelease.Child("values"), "", err.Error()))
+	}
+
+	return invalidVCluster(vCluster, allErrs)
+}
+
+func invalidVCluster(vCluster *v1alpha1.VCluster, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(v1alpha1.GroupVersion.WithKind("VCluster").GroupKind(), vCluster.Name, allErrs)
+}
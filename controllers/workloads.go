@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/conditions"
+)
+
+const (
+	// ControlPlaneWorkloadsNotReadyReason is used when a statefulset or deployment of the vcluster
+	// release has replicas that are not ready.
+	ControlPlaneWorkloadsNotReadyReason = "ControlPlaneWorkloadsNotReady"
+)
+
+// checkControlPlaneWorkloads checks if all statefulsets and deployments of the vcluster release have
+// all their replicas ready, as the api server might answer while other control plane components
+// like coredns or konnectivity are still broken
+func (r *VClusterReconciler) checkControlPlaneWorkloads(ctx context.Context, vCluster *v1alpha1.VCluster) (bool, error) {
+	if !r.CheckControlPlaneWorkloads {
+		conditions.Delete(vCluster, v1alpha1.ControlPlaneWorkloadsReadyCondition)
+		return true, nil
+	}
+
+	selector := client.MatchingLabels{"release": vCluster.Name}
+	statefulSets := &appsv1.StatefulSetList{}
+	err := r.Client.List(ctx, statefulSets, client.InNamespace(vCluster.Namespace), selector)
+	if err != nil {
+		conditions.MarkFalse(vCluster, v1alpha1.ControlPlaneWorkloadsReadyCondition, ControlPlaneWorkloadsNotReadyReason, v1alpha1.ConditionSeverityWarning, "%v", err)
+		return false, fmt.Errorf("list statefulsets: %w", err)
+	}
+	deployments := &appsv1.DeploymentList{}
+	err = r.Client.List(ctx, deployments, client.InNamespace(vCluster.Namespace), selector)
+	if err != nil {
+		conditions.MarkFalse(vCluster, v1alpha1.ControlPlaneWorkloadsReadyCondition, ControlPlaneWorkloadsNotReadyReason, v1alpha1.ConditionSeverityWarning, "%v", err)
+		return false, fmt.Errorf("list deployments: %w", err)
+	}
+
+	notReady := []string{}
+	for _, statefulSet := range statefulSets.Items {
+		if !workloadReady(statefulSet.Spec.Replicas, statefulSet.Status.ReadyReplicas, statefulSet.Generation, statefulSet.Status.ObservedGeneration) {
+			notReady = append(notReady, fmt.Sprintf("statefulset %s (%d/%d ready)", statefulSet.Name, statefulSet.Status.ReadyReplicas, desiredReplicas(statefulSet.Spec.Replicas)))
+		}
+	}
+	for _, deployment := range deployments.Items {
+		if !workloadReady(deployment.Spec.Replicas, deployment.Status.ReadyReplicas, deployment.Generation, deployment.Status.ObservedGeneration) {
+			notReady = append(notReady, fmt.Sprintf("deployment %s (%d/%d ready)", deployment.Name, deployment.Status.ReadyReplicas, desiredReplicas(deployment.Spec.Replicas)))
+		}
+	}
+
+	if len(statefulSets.Items)+len(deployments.Items) == 0 {
+		conditions.MarkFalse(vCluster, v1alpha1.ControlPlaneWorkloadsReadyCondition, ControlPlaneWorkloadsNotReadyReason, v1alpha1.ConditionSeverityWarning, "no statefulsets or deployments of release %s found", vCluster.Name)
+		return false, nil
+	} else if len(notReady) > 0 {
+		sort.Strings(notReady)
+		conditions.MarkFalse(vCluster, v1alpha1.ControlPlaneWorkloadsReadyCondition, ControlPlaneWorkloadsNotReadyReason, v1alpha1.ConditionSeverityWarning, "%s", strings.Join(notReady, ", "))
+		return false, nil
+	}
+
+	conditions.MarkTrue(vCluster, v1alpha1.ControlPlaneWorkloadsReadyCondition)
+	return true, nil
+}
+
+func workloadReady(replicas *int32, readyReplicas int32, generation, observedGeneration int64) bool {
+	return observedGeneration >= generation && readyReplicas >= desiredReplicas(replicas)
+}
+
+// desiredReplicas returns the replicas of a workload spec, which default to 1
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
@@ -19,16 +19,24 @@ package main
 import (
 	"flag"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -36,6 +44,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	infrastructurev1alpha1 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha1"
+	infrastructurev1alpha2 "github.com/loft-sh/cluster-api-provider-vcluster/api/v1alpha2"
 	"github.com/loft-sh/cluster-api-provider-vcluster/controllers"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/util/kubeconfighelper"
@@ -50,8 +59,10 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(clusterv1beta1.AddToScheme(scheme))
 
 	utilruntime.Must(infrastructurev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -60,12 +71,44 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var namespace string
+	var maxValuesSize int
+	var allowedChartRepos string
+	var allowedCAConfigMapNamespaces string
+	var allowedHelmBinaries string
+	var chartChannelsConfigMap string
+	var stalledReconcileTimeout time.Duration
+	var warnOrphanedReleases bool
+	var streamHelmOutput bool
+	var chartCacheDir string
+	var reconcileStaleThreshold time.Duration
+	var validateValuesSchema bool
+	var validateKubernetesVersion bool
+	var enableWebhooks bool
+	var checkControlPlaneWorkloads bool
+	var requeueJitter float64
+	var driftDetectionInterval time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&namespace, "namespace", "", "The namespace watched by the controller manager.")
+	flag.IntVar(&maxValuesSize, "max-values-size", 0, "The maximum size in bytes of the helm values of a VCluster. Set to 0 to disable the limit.")
+	flag.StringVar(&allowedChartRepos, "allowed-chart-repos", "", "Comma separated list of chart repository urls VClusters are allowed to use, repositories below the path of an allowed url are allowed as well. Empty allows all repositories.")
+	flag.StringVar(&allowedCAConfigMapNamespaces, "allowed-ca-config-map-namespaces", "", "Comma separated list of namespaces besides their own that VClusters are allowed to publish their CA ConfigMap to. Empty restricts VClusters to their own namespace.")
+	flag.DurationVar(&stalledReconcileTimeout, "stalled-reconcile-timeout", 30*time.Minute, "The time after which a VCluster whose generation could not be reconciled is marked as stalled. Set to 0 to disable the detection.")
+	flag.StringVar(&allowedHelmBinaries, "allowed-helm-binaries", "", "Comma separated list of absolute paths of the helm binaries VClusters are allowed to select via the vcluster.loft.sh/helm-binary annotation. Empty restricts VClusters to the default helm binary.")
+	flag.StringVar(&chartChannelsConfigMap, "chart-channels-configmap", "", "The namespace/name of the ConfigMap that maps chart version channels (e.g. stable) to chart versions.")
+	flag.BoolVar(&warnOrphanedReleases, "warn-orphaned-releases", false, "Log vcluster helm releases in the namespaces of VClusters that have no matching VCluster.")
+	flag.BoolVar(&streamHelmOutput, "stream-helm-output", false, "Log the output of helm line by line at verbosity 1.")
+	flag.DurationVar(&reconcileStaleThreshold, "reconcile-stale-threshold", 0, "The time after which the health check fails if no VCluster was reconciled successfully. Set to 0 to disable the check.")
+	flag.BoolVar(&validateValuesSchema, "validate-values-schema", false, "Validate the helm values against the values.schema.json of the chart and report violations in the ValuesSchemaValid condition.")
+	flag.BoolVar(&validateKubernetesVersion, "validate-kubernetes-version", false, "Check the kubernetes version of VClusters against the kubeVersion constraint of charts that are installed from a repository, which fetches the repository index. Local charts are always checked.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the webhooks that default and validate VClusters. Requires the webhook serving certificates and the webhook configurations of config/webhook.")
+	flag.StringVar(&chartCacheDir, "chart-cache-dir", "", "The directory of cached <chart>-<version>.tgz files that are installed instead of the repository chart. A <chart>-<version>.tgz.sha256 file next to it is verified. Defaults to the working directory.")
+	flag.BoolVar(&checkControlPlaneWorkloads, "check-control-plane-workloads", false, "Only mark a VCluster ready once all statefulsets and deployments of its helm release have their replicas ready.")
+	flag.DurationVar(&driftDetectionInterval, "drift-detection-interval", 0, "The interval in which the values of deployed helm releases are compared to the desired values of their VCluster. Drift is reported in the DriftDetected condition and corrected for VClusters with the vcluster.loft.sh/correct-drift annotation. Set to 0 to disable the drift detection.")
+	flag.Float64Var(&requeueJitter, "requeue-jitter", 0.1, "The fraction by which the requeue interval of ready VClusters is randomized in either direction (e.g. 0.1 for ±10%). Must be in [0, 1), set to 0 to disable the jitter.")
 
 	opts := zap.Options{
 		Development: true,
@@ -75,6 +118,20 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if requeueJitter < 0 || requeueJitter >= 1 {
+		setupLog.Error(nil, "requeue-jitter must be in [0, 1)")
+		os.Exit(1)
+	}
+
+	helmBinaries := splitList(allowedHelmBinaries)
+	for i, helmBinary := range helmBinaries {
+		if !filepath.IsAbs(helmBinary) {
+			setupLog.Error(nil, "allowed-helm-binaries must be absolute paths", "helmBinary", helmBinary)
+			os.Exit(1)
+		}
+		helmBinaries[i] = filepath.Clean(helmBinary)
+	}
+
 	var namespaces map[string]cache.Config
 	if namespace != "" {
 		namespaces = map[string]cache.Config{
@@ -96,6 +153,13 @@ func main() {
 		Cache: cache.Options{
 			DefaultNamespaces: namespaces,
 		},
+		// Secrets and ConfigMaps are read from the api server, as caching them would hold every
+		// Secret and ConfigMap of the cluster in memory
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}},
+			},
+		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -117,24 +181,76 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.VClusterReconciler{
-		Client:             mgr.GetClient(),
-		HelmClient:         helm.NewClient(rawConfig),
-		HelmSecrets:        helm.NewSecrets(mgr.GetClient()),
-		Log:                log,
-		Scheme:             mgr.GetScheme(),
-		ClientConfigGetter: controllers.NewClientConfigGetter(),
-		HTTPClientGetter:   controllers.NewHTTPClientGetter(),
-	}).SetupWithManager(mgr); err != nil {
+	var channelsConfigMap types.NamespacedName
+	if chartChannelsConfigMap != "" {
+		configMapNamespace, configMapName, found := strings.Cut(chartChannelsConfigMap, "/")
+		if !found {
+			setupLog.Error(nil, "chart-channels-configmap must be in the format namespace/name")
+			os.Exit(1)
+		}
+		channelsConfigMap = types.NamespacedName{Namespace: configMapNamespace, Name: configMapName}
+	}
+
+	helmSecrets := helm.NewSecrets(mgr.GetClient())
+	helmOptions := []helm.ClientOption{helm.WithReleaseSecrets(helmSecrets)}
+	if streamHelmOutput {
+		helmOptions = append(helmOptions, helm.WithOutputLogger(log.WithName("helm")))
+	}
+
+	var chartMetadataGetter controllers.ChartMetadataGetter
+	if validateKubernetesVersion {
+		chartMetadataGetter = controllers.NewChartMetadataGetter()
+	}
+	var valuesSchemaGetter controllers.ValuesSchemaGetter
+	if validateValuesSchema {
+		valuesSchemaGetter = controllers.NewValuesSchemaGetter()
+	}
+
+	reconciler := &controllers.VClusterReconciler{
+		Client:                       mgr.GetClient(),
+		HelmClient:                   helm.NewClient(rawConfig, helmOptions...),
+		HelmSecrets:                  helmSecrets,
+		Log:                          log,
+		Scheme:                       mgr.GetScheme(),
+		ClientConfigGetter:           controllers.NewClientConfigGetter(),
+		HTTPClientGetter:             controllers.NewHTTPClientGetter(),
+		ChartMetadataGetter:          chartMetadataGetter,
+		ValuesSchemaGetter:           valuesSchemaGetter,
+		HostClient:                   kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+		MaxValuesSize:                maxValuesSize,
+		AllowedChartRepos:            splitList(allowedChartRepos),
+		AllowedCAConfigMapNamespaces: splitList(allowedCAConfigMapNamespaces),
+		AllowedHelmBinaries:          helmBinaries,
+		ChartChannelsConfigMap:       channelsConfigMap,
+		StalledReconcileTimeout:      stalledReconcileTimeout,
+		WarnOrphanedReleases:         warnOrphanedReleases,
+		CheckControlPlaneWorkloads:   checkControlPlaneWorkloads,
+		RequeueJitter:                requeueJitter,
+		DriftDetectionInterval:       driftDetectionInterval,
+		ChartCacheDir:                chartCacheDir,
+		ReconcileStaleThreshold:      reconcileStaleThreshold,
+		Recorder:                     mgr.GetEventRecorderFor("vcluster-controller"),
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VCluster")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&controllers.VClusterWebhook{MaxValuesSize: maxValuesSize, AllowedCAConfigMapNamespaces: splitList(allowedCAConfigMapNamespaces)}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VCluster")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
+	if err := mgr.AddHealthzCheck("reconcile", reconciler.HealthCheck); err != nil {
+		setupLog.Error(err, "unable to set up reconcile health check")
+		os.Exit(1)
+	}
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
@@ -146,3 +262,15 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
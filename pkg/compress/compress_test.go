@@ -1,7 +1,82 @@
 package compress
 
-import "testing"
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
 
-func TestCompress(_ *testing.T) {
-	// TODO
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name:  "text",
+			input: "controlPlane:\n  distro:\n    k8s:\n      enabled: true\n",
+		},
+		{
+			name:  "tgz",
+			input: chartArchive(t, "vcluster/Chart.yaml", "name: vcluster\nversion: 0.22.1\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := Compress(tt.input)
+			assert.NoError(t, err)
+
+			uncompressed, err := Uncompress(compressed)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, uncompressed)
+		})
+	}
+}
+
+func TestUncompressTgz(t *testing.T) {
+	compressed, err := Compress(chartArchive(t, "vcluster/Chart.yaml", "name: vcluster\n"))
+	assert.NoError(t, err)
+	uncompressed, err := Uncompress(compressed)
+	assert.NoError(t, err)
+
+	gz, err := gzip.NewReader(bytes.NewReader([]byte(uncompressed)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "vcluster/Chart.yaml", header.Name)
+	content, err := io.ReadAll(tr)
+	assert.NoError(t, err)
+	assert.Equal(t, "name: vcluster\n", string(content))
+}
+
+func TestUncompressInvalid(t *testing.T) {
+	_, err := Uncompress("not base64!")
+	assert.Error(t, err)
+}
+
+func chartArchive(t *testing.T, name, content string) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return b.String()
 }
@@ -1,13 +1,18 @@
 package helm
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -16,6 +21,9 @@ import (
 
 var CommandPath = "./helm"
 
+// redactedPassword replaces the repository password in printed and logged helm arguments
+const redactedPassword = "REDACTED"
+
 // UpgradeOptions holds all the options for upgrading / installing a chart
 type UpgradeOptions struct {
 	Chart string
@@ -26,26 +34,57 @@ type UpgradeOptions struct {
 	Values          string
 	SetValues       map[string]string
 	SetStringValues map[string]string
+	// SetFileValues sets the value of the key to the content of a file. The value is either the
+	// path of an existing file or the content itself, which is written to a temp file.
+	SetFileValues map[string]string
+
+	// ValuesFiles are additional values that are passed after Values, later entries take precedence.
+	// An entry is either the path of an existing values file or an inline values yaml.
+	ValuesFiles []string
 
 	Username string
 	Password string
+	// CAFile is the path of a CA bundle file to verify the repository with
+	CAFile string
+	// CAData is a PEM encoded CA bundle to verify the repository with, it is always written to a
+	// temp file and takes precedence over CAFile
+	CAData string
 
 	Atomic          bool
 	Force           bool
 	CreateNamespace bool
 
+	// Wait makes helm wait until all resources of the release are ready
+	Wait bool
+	// Timeout is the time helm waits for the release, zero uses the helm default
+	Timeout time.Duration
+	// DryRun only renders and validates the release without changing it
+	DryRun bool
+
 	InsecureSkipTLSVerify bool
 
+	// Description is stored as description of the release revision
+	Description string
+	// Labels are added to the release secret after a successful install or upgrade. Helm has no
+	// flag for them, so they are only set if the client was created with WithReleaseSecrets.
+	Labels map[string]string
+
+	// HelmPath overrides the helm binary of the client for this install or upgrade
+	HelmPath string
+
 	ExtraArgs []string
 }
 
-// Client defines the interface how to interact with helm
+// Client defines the interface how to interact with helm. The helm process is killed if the
+// context is cancelled.
 type Client interface {
-	Install(name, namespace string, options UpgradeOptions) error
-	Upgrade(name, namespace string, options UpgradeOptions) error
-	Rollback(name, namespace string, revision string) error
-	Delete(name, namespace string) error
-	Exists(name, namespace string) (bool, error)
+	Install(ctx context.Context, name, namespace string, options UpgradeOptions) error
+	Upgrade(ctx context.Context, name, namespace string, options UpgradeOptions) error
+	Rollback(ctx context.Context, name, namespace string, revision string) error
+	Delete(ctx context.Context, name, namespace string) error
+	Exists(ctx context.Context, name, namespace string) (bool, error)
+	// Template renders the manifests of the chart without applying them
+	Template(ctx context.Context, name, namespace string, options UpgradeOptions) ([]byte, error)
 }
 
 type client struct {
@@ -54,41 +93,80 @@ type client struct {
 
 	stderr io.Writer
 	stdout io.Writer
+
+	// log streams the helm output line by line, optional
+	log *logr.Logger
+
+	// secrets is used to label the release secrets, optional
+	secrets *Secrets
+}
+
+// ClientOption configures the helm client
+type ClientOption func(*client)
+
+// WithOutputLogger streams the output of helm line by line to the logger at verbosity 1
+func WithOutputLogger(log logr.Logger) ClientOption {
+	return func(c *client) {
+		c.log = &log
+	}
+}
+
+// WithReleaseSecrets makes the client add the labels of the upgrade options to the release secret
+func WithReleaseSecrets(secrets *Secrets) ClientOption {
+	return func(c *client) {
+		c.secrets = secrets
+	}
 }
 
 // NewClient creates a new helm client from the given config
-func NewClient(config *clientcmdapi.Config) Client {
-	return &client{
+func NewClient(config *clientcmdapi.Config, options ...ClientOption) Client {
+	c := &client{
 		config:   config,
 		helmPath: CommandPath,
 	}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
 }
 
 // NewClientWithStreams creates a new helm client from the given config
-func NewClientWithStreams(helmPath string, config *clientcmdapi.Config, stdout, stderr io.Writer) Client {
-	return &client{
+func NewClientWithStreams(helmPath string, config *clientcmdapi.Config, stdout, stderr io.Writer, options ...ClientOption) Client {
+	c := &client{
 		config:   config,
 		helmPath: helmPath,
 
 		stderr: stderr,
 		stdout: stdout,
 	}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
 }
 
-func (c *client) exec(args []string) error {
+func (c *client) exec(ctx context.Context, name, namespace string, args []string) error {
 	if len(args) == 0 {
 		return nil
 	}
 
-	fmt.Println("helm " + strings.Join(args, " "))
-	cmd := exec.Command(c.helmPath, args...)
+	fmt.Println("helm " + strings.Join(redactArgs(args), " "))
+	cmd := exec.CommandContext(ctx, c.helmPath, args...)
 	if c.stdout != nil {
 		cmd.Stdout = c.stdout
 		cmd.Stderr = c.stderr
 		return cmd.Run()
 	}
 
-	output, err := cmd.CombinedOutput()
+	var output []byte
+	var err error
+	if c.log != nil {
+		output, err = c.runStreamed(cmd, c.log.WithValues("release", name, "namespace", namespace, "command", args[0]))
+	} else {
+		output, err = cmd.CombinedOutput()
+	}
 	if err != nil {
 		if strings.Contains(string(output), "release: not found") {
 			return nil
@@ -96,7 +174,7 @@ func (c *client) exec(args []string) error {
 		klog.TODO().Error(
 			err,
 			"error executing helm",
-			"args", args,
+			"args", redactArgs(args),
 			"output", string(output),
 		)
 		return fmt.Errorf("error executing helm %s: %s", args[0], string(output))
@@ -105,7 +183,82 @@ func (c *client) exec(args []string) error {
 	return nil
 }
 
-func (c *client) Rollback(name, namespace string, revision string) error {
+// redactArgs returns a copy of the helm arguments with the repository password replaced, so the
+// arguments can be printed and logged
+func redactArgs(args []string) []string {
+	redacted := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--password" && i+1 < len(args):
+			redacted = append(redacted, args[i], redactedPassword)
+			i++
+		case strings.HasPrefix(args[i], "--password="):
+			redacted = append(redacted, "--password="+redactedPassword)
+		default:
+			redacted = append(redacted, args[i])
+		}
+	}
+
+	return redacted
+}
+
+// runStreamed runs the command, logs its output line by line and returns the combined output
+func (c *client) runStreamed(cmd *exec.Cmd, log logr.Logger) ([]byte, error) {
+	// stdout and stderr are copied concurrently
+	output := &lockedBuffer{}
+	stdout := &lineLogger{log: log, stream: "stdout"}
+	stderr := &lineLogger{log: log, stream: "stderr"}
+	cmd.Stdout = io.MultiWriter(output, stdout)
+	cmd.Stderr = io.MultiWriter(output, stderr)
+
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	return output.buffer.Bytes(), err
+}
+
+type lockedBuffer struct {
+	m      sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buffer.Write(p)
+}
+
+// lineLogger is a writer that logs every complete line at verbosity 1
+type lineLogger struct {
+	log    logr.Logger
+	stream string
+	buffer []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.buffer = append(l.buffer, p...)
+	for {
+		i := bytes.IndexByte(l.buffer, '\n')
+		if i < 0 {
+			break
+		}
+
+		l.log.V(1).Info(string(l.buffer[:i]), "stream", l.stream)
+		l.buffer = l.buffer[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush logs the last line if it wasn't terminated by a newline
+func (l *lineLogger) Flush() {
+	if len(l.buffer) > 0 {
+		l.log.V(1).Info(string(l.buffer), "stream", l.stream)
+		l.buffer = nil
+	}
+}
+
+func (c *client) Rollback(ctx context.Context, name, namespace string, revision string) error {
 	kubeConfig, err := WriteKubeConfig(c.config)
 	if err != nil {
 		return err
@@ -117,19 +270,92 @@ func (c *client) Rollback(name, namespace string, revision string) error {
 		args = append(args, revision)
 	}
 	args = append(args, "--namespace", namespace, "--kubeconfig", kubeConfig)
-	return c.exec(args)
+	return c.exec(ctx, name, namespace, args)
 }
 
-func (c *client) Install(name, namespace string, options UpgradeOptions) error {
-	return c.run(name, namespace, options, "install", options.ExtraArgs)
+func (c *client) Install(ctx context.Context, name, namespace string, options UpgradeOptions) error {
+	err := c.run(ctx, name, namespace, options, "install", options.ExtraArgs)
+	if err != nil {
+		return err
+	}
+
+	return c.labelRelease(ctx, name, namespace, options)
 }
 
-func (c *client) Upgrade(name, namespace string, options UpgradeOptions) error {
+func (c *client) Upgrade(ctx context.Context, name, namespace string, options UpgradeOptions) error {
 	options.ExtraArgs = append(options.ExtraArgs, "--install")
-	return c.run(name, namespace, options, "upgrade", options.ExtraArgs)
+	err := c.run(ctx, name, namespace, options, "upgrade", options.ExtraArgs)
+	if err != nil {
+		return err
+	}
+
+	return c.labelRelease(ctx, name, namespace, options)
 }
 
-func (c *client) run(name, namespace string, options UpgradeOptions, command string, extraArgs []string) error {
+// labelRelease adds the labels of the options to the secret of the deployed release
+func (c *client) labelRelease(ctx context.Context, name, namespace string, options UpgradeOptions) error {
+	if c.secrets == nil || len(options.Labels) == 0 || options.DryRun {
+		return nil
+	}
+
+	err := c.secrets.AddLabels(ctx, name, namespace, options.Labels)
+	if err != nil {
+		return fmt.Errorf("label release %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Template runs helm template with the values and flags of the options and returns the rendered
+// manifests. Options that only apply to a deployed release are ignored.
+func (c *client) Template(ctx context.Context, name, namespace string, options UpgradeOptions) ([]byte, error) {
+	options.Atomic = false
+	options.Force = false
+	options.Wait = false
+	options.Timeout = 0
+	options.DryRun = false
+
+	var manifests []byte
+	err := c.runWith(name, namespace, options, "template", options.ExtraArgs, func(c *client, args []string) error {
+		var err error
+		manifests, err = c.output(ctx, args)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifests, nil
+}
+
+// output runs helm and returns its stdout
+func (c *client) output(ctx context.Context, args []string) ([]byte, error) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, c.helmPath, args...)
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		klog.TODO().Error(
+			err,
+			"error executing helm",
+			"args", redactArgs(args),
+			"output", stderr.String(),
+		)
+		return nil, fmt.Errorf("error executing helm %s: %s", args[0], stderr.String())
+	}
+
+	return output, nil
+}
+
+func (c *client) run(ctx context.Context, name, namespace string, options UpgradeOptions, command string, extraArgs []string) error {
+	return c.runWith(name, namespace, options, command, extraArgs, func(c *client, args []string) error {
+		return c.exec(ctx, name, namespace, args)
+	})
+}
+
+// runWith builds the helm arguments of the options and passes them to execute, which is called
+// with the client of the helm binary to use
+func (c *client) runWith(name, namespace string, options UpgradeOptions, command string, extraArgs []string, execute func(c *client, args []string) error) error {
 	kubeConfig, err := WriteKubeConfig(c.config)
 	if err != nil {
 		return err
@@ -156,6 +382,17 @@ func (c *client) run(name, namespace string, options UpgradeOptions, command str
 		if options.Password != "" {
 			args = append(args, "--password", options.Password)
 		}
+		if options.CAData != "" {
+			caFile, err := writeValuesFile(options.CAData)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(caFile)
+
+			args = append(args, "--ca-file", caFile)
+		} else if options.CAFile != "" {
+			args = append(args, "--ca-file", options.CAFile)
+		}
 	}
 
 	args = append(args, "--kubeconfig", kubeConfig, "--namespace", namespace)
@@ -163,30 +400,33 @@ func (c *client) run(name, namespace string, options UpgradeOptions, command str
 	if options.CreateNamespace {
 		args = append(args, "--create-namespace")
 	}
+	if options.DryRun {
+		args = append(args, "--dry-run")
+	}
 
 	// Values
 	if options.Values != "" {
-		// Create temp file
-		tempFile, err := os.CreateTemp("", "")
+		valuesFile, err := writeValuesFile(options.Values)
 		if err != nil {
-			return errors.Wrap(err, "create temp file")
+			return err
 		}
+		defer os.Remove(valuesFile)
 
-		// Write to temp file
-		_, err = tempFile.Write([]byte(options.Values))
-		if err != nil {
-			os.Remove(tempFile.Name())
-			return errors.Wrap(err, "write temp file")
+		args = append(args, "--values", valuesFile)
+	}
+	for _, values := range options.ValuesFiles {
+		if isValuesFilePath(values) {
+			args = append(args, "--values", values)
+			continue
 		}
 
-		// Close temp file
-		tempFile.Close()
-		defer os.Remove(tempFile.Name())
-
-		// Wait quickly so helm will find the file
-		time.Sleep(time.Millisecond)
+		valuesFile, err := writeValuesFile(values)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(valuesFile)
 
-		args = append(args, "--values", tempFile.Name())
+		args = append(args, "--values", valuesFile)
 	}
 
 	// Set values
@@ -221,6 +461,27 @@ func (c *client) run(name, namespace string, options UpgradeOptions, command str
 		args = append(args, setString)
 	}
 
+	// Set file values, sorted to get a stable command
+	setFileKeys := make([]string, 0, len(options.SetFileValues))
+	for key := range options.SetFileValues {
+		setFileKeys = append(setFileKeys, key)
+	}
+	sort.Strings(setFileKeys)
+	for _, key := range setFileKeys {
+		value := options.SetFileValues[key]
+		if !isValuesFilePath(value) {
+			valueFile, err := writeValuesFile(value)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(valueFile)
+
+			value = valueFile
+		}
+
+		args = append(args, "--set-file", key+"="+value)
+	}
+
 	if options.Force {
 		args = append(args, "--force")
 	}
@@ -230,11 +491,26 @@ func (c *client) run(name, namespace string, options UpgradeOptions, command str
 	if options.InsecureSkipTLSVerify {
 		args = append(args, "--insecure-skip-tls-verify")
 	}
+	if options.Wait {
+		args = append(args, "--wait")
+	}
+	if options.Timeout > 0 {
+		args = append(args, "--timeout", options.Timeout.String())
+	}
+	if options.Description != "" {
+		args = append(args, "--description", options.Description)
+	}
+
+	if options.HelmPath != "" {
+		withPath := *c
+		withPath.helmPath = options.HelmPath
+		return execute(&withPath, args)
+	}
 
-	return c.exec(args)
+	return execute(c, args)
 }
 
-func (c *client) Delete(name, namespace string) error {
+func (c *client) Delete(ctx context.Context, name, namespace string) error {
 	kubeConfig, err := WriteKubeConfig(c.config)
 	if err != nil {
 		return err
@@ -242,10 +518,10 @@ func (c *client) Delete(name, namespace string) error {
 	defer os.Remove(kubeConfig)
 
 	args := []string{"delete", name, "--namespace", namespace, "--kubeconfig", kubeConfig}
-	return c.exec(args)
+	return c.exec(ctx, name, namespace, args)
 }
 
-func (c *client) Exists(name, namespace string) (bool, error) {
+func (c *client) Exists(ctx context.Context, name, namespace string) (bool, error) {
 	kubeConfig, err := WriteKubeConfig(c.config)
 	if err != nil {
 		return false, err
@@ -253,7 +529,7 @@ func (c *client) Exists(name, namespace string) (bool, error) {
 	defer os.Remove(kubeConfig)
 
 	args := []string{"status", name, "--namespace", namespace, "--kubeconfig", kubeConfig}
-	output, err := exec.Command(c.helmPath, args...).CombinedOutput()
+	output, err := exec.CommandContext(ctx, c.helmPath, args...).CombinedOutput()
 	if err != nil {
 		if strings.Contains(string(output), "release: not found") {
 			return false, nil
@@ -309,3 +585,36 @@ func WriteKubeConfig(configRaw *clientcmdapi.Config) (string, error) {
 
 	return tempFile.Name(), nil
 }
+
+// writeValuesFile writes the values into a temp file and returns its path
+func writeValuesFile(values string) (string, error) {
+	// Create temp file
+	tempFile, err := os.CreateTemp("", "")
+	if err != nil {
+		return "", errors.Wrap(err, "create temp file")
+	}
+
+	// Write to temp file
+	_, err = tempFile.Write([]byte(values))
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", errors.Wrap(err, "write temp file")
+	}
+
+	// Close temp file
+	tempFile.Close()
+
+	// Wait quickly so helm will find the file
+	time.Sleep(time.Millisecond)
+	return tempFile.Name(), nil
+}
+
+// isValuesFilePath checks if the values are a path to an existing file instead of inline values
+func isValuesFilePath(values string) bool {
+	if strings.ContainsAny(values, "\n:") {
+		return false
+	}
+
+	stat, err := os.Stat(values)
+	return err == nil && stat.Mode().IsRegular()
+}
@@ -0,0 +1,282 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// newEchoClient returns a client whose helm binary prints the arguments it was called with
+func newEchoClient(t *testing.T) (Client, *bytes.Buffer) {
+	helmPath := filepath.Join(t.TempDir(), "helm")
+	err := os.WriteFile(helmPath, []byte("#!/bin/sh\necho \"$@\"\n"), 0o755)
+	assert.NoError(t, err)
+
+	stdout := &bytes.Buffer{}
+	return NewClientWithStreams(helmPath, clientcmdapi.NewConfig(), stdout, &bytes.Buffer{}), stdout
+}
+
+func TestUpgradeFlags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  UpgradeOptions
+		expected []string
+		missing  []string
+	}{
+		{
+			name:    "no wait",
+			options: UpgradeOptions{Path: "./vcluster.tgz"},
+			missing: []string{"--wait", "--timeout", "--dry-run"},
+		},
+		{
+			name:     "wait",
+			options:  UpgradeOptions{Path: "./vcluster.tgz", Wait: true},
+			expected: []string{"--wait"},
+			missing:  []string{"--timeout"},
+		},
+		{
+			name:     "wait with timeout",
+			options:  UpgradeOptions{Path: "./vcluster.tgz", Wait: true, Timeout: 5 * time.Minute},
+			expected: []string{"--wait", "--timeout 5m0s"},
+		},
+		{
+			name:     "dry run",
+			options:  UpgradeOptions{Path: "./vcluster.tgz", DryRun: true},
+			expected: []string{"--dry-run"},
+			missing:  []string{"--wait"},
+		},
+		{
+			name:     "repository credentials",
+			options:  UpgradeOptions{Chart: "vcluster", Repo: "https://charts.loft.sh", Username: "user", Password: "secret", CAData: "-----BEGIN CERTIFICATE-----\n"},
+			expected: []string{"--username user", "--password secret", "--ca-file "},
+		},
+		{
+			name:     "ca file",
+			options:  UpgradeOptions{Chart: "vcluster", Repo: "https://charts.loft.sh", CAFile: "/etc/ssl/certs/repo.crt"},
+			expected: []string{"--ca-file /etc/ssl/certs/repo.crt"},
+		},
+		{
+			name:     "ca data that looks like a path",
+			options:  UpgradeOptions{Chart: "vcluster", Repo: "https://charts.loft.sh", CAData: "/etc/passwd"},
+			expected: []string{"--ca-file "},
+			missing:  []string{"--ca-file /etc/passwd"},
+		},
+		{
+			name:     "description",
+			options:  UpgradeOptions{Path: "./vcluster.tgz", Description: "generation 2"},
+			expected: []string{"--description generation 2"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			helmClient, stdout := newEchoClient(t)
+			err := helmClient.Upgrade(context.Background(), "test", "default", testCase.options)
+			assert.NoError(t, err)
+
+			args := strings.TrimSpace(stdout.String())
+			for _, expected := range testCase.expected {
+				assert.Contains(t, args, expected)
+			}
+			for _, missing := range testCase.missing {
+				assert.NotContains(t, args, missing)
+			}
+		})
+	}
+}
+
+func TestUpgradeValuesFilesOrder(t *testing.T) {
+	baseValues := filepath.Join(t.TempDir(), "base.yaml")
+	err := os.WriteFile(baseValues, []byte("controlPlane: {}\n"), 0o644)
+	assert.NoError(t, err)
+
+	helmClient, stdout := newEchoClient(t)
+	err = helmClient.Upgrade(context.Background(), "test", "default", UpgradeOptions{
+		Path:        "./vcluster.tgz",
+		Values:      "sync: {}\n",
+		ValuesFiles: []string{baseValues, "experimental: {}\n"},
+	})
+	assert.NoError(t, err)
+
+	// collect the values files in the order they were passed
+	valuesFiles := []string{}
+	args := strings.Fields(stdout.String())
+	for i, arg := range args {
+		if arg == "--values" && i+1 < len(args) {
+			valuesFiles = append(valuesFiles, args[i+1])
+		}
+	}
+	if !assert.Len(t, valuesFiles, 3) {
+		return
+	}
+	assert.Equal(t, baseValues, valuesFiles[1])
+	assert.NotEqual(t, baseValues, valuesFiles[2])
+
+	// the inline values files are removed after the command
+	for _, valuesFile := range []string{valuesFiles[0], valuesFiles[2]} {
+		_, err = os.Stat(valuesFile)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestUpgradeSetFileValues(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	err := os.WriteFile(caFile, []byte("-----BEGIN CERTIFICATE-----\n"), 0o644)
+	assert.NoError(t, err)
+
+	helmClient, stdout := newEchoClient(t)
+	err = helmClient.Upgrade(context.Background(), "test", "default", UpgradeOptions{
+		Path: "./vcluster.tgz",
+		SetFileValues: map[string]string{
+			"tls.ca":       caFile,
+			"init.objects": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: test\n",
+		},
+	})
+	assert.NoError(t, err)
+
+	setFiles := map[string]string{}
+	args := strings.Fields(stdout.String())
+	for i, arg := range args {
+		if arg == "--set-file" && i+1 < len(args) {
+			key, value, _ := strings.Cut(args[i+1], "=")
+			setFiles[key] = value
+		}
+	}
+	if !assert.Len(t, setFiles, 2) {
+		return
+	}
+	assert.Equal(t, caFile, setFiles["tls.ca"])
+	assert.NotEqual(t, "", setFiles["init.objects"])
+
+	// the file written for the inline content is removed after the command
+	_, err = os.Stat(setFiles["init.objects"])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUpgradeContextCancel(t *testing.T) {
+	helmPath := filepath.Join(t.TempDir(), "helm")
+	err := os.WriteFile(helmPath, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+
+	start := time.Now()
+	helmClient := NewClientWithStreams(helmPath, clientcmdapi.NewConfig(), &bytes.Buffer{}, &bytes.Buffer{})
+	err = helmClient.Upgrade(ctx, "test", "default", UpgradeOptions{Path: "./vcluster.tgz"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second*10)
+}
+
+func TestUpgradeOutputLogger(t *testing.T) {
+	helmPath := filepath.Join(t.TempDir(), "helm")
+	err := os.WriteFile(helmPath, []byte("#!/bin/sh\necho installing\necho waiting >&2\nprintf done\n"), 0o755)
+	assert.NoError(t, err)
+
+	m := sync.Mutex{}
+	lines := &bytes.Buffer{}
+	log := funcr.New(func(_, args string) {
+		m.Lock()
+		defer m.Unlock()
+		lines.WriteString(args + "\n")
+	}, funcr.Options{Verbosity: 1})
+
+	helmClient := NewClient(clientcmdapi.NewConfig(), WithOutputLogger(log))
+	helmClient.(*client).helmPath = helmPath
+	err = helmClient.Upgrade(context.Background(), "test", "default", UpgradeOptions{Path: "./vcluster.tgz"})
+	assert.NoError(t, err)
+
+	logged := lines.String()
+	assert.Contains(t, logged, `"msg"="installing" "release"="test" "namespace"="default" "command"="upgrade" "stream"="stdout"`)
+	assert.Contains(t, logged, `"msg"="waiting"`)
+	assert.Contains(t, logged, `"stream"="stderr"`)
+	assert.Contains(t, logged, `"msg"="done"`)
+}
+
+func TestUpgradeHelmPath(t *testing.T) {
+	helmClient, stdout := newEchoClient(t)
+	helmPath := filepath.Join(t.TempDir(), "helm-3.14")
+	err := os.WriteFile(helmPath, []byte("#!/bin/sh\necho helm-3.14 \"$@\"\n"), 0o755)
+	assert.NoError(t, err)
+
+	err = helmClient.Upgrade(context.Background(), "test", "default", UpgradeOptions{Path: "./vcluster.tgz", HelmPath: helmPath})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout.String(), "helm-3.14 upgrade test"))
+
+	stdout.Reset()
+	err = helmClient.Upgrade(context.Background(), "test", "default", UpgradeOptions{Path: "./vcluster.tgz"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout.String(), "upgrade test"))
+}
+
+func TestTemplate(t *testing.T) {
+	// the fake helm binary renders a manifest that includes its arguments as a comment
+	helmPath := filepath.Join(t.TempDir(), "helm")
+	err := os.WriteFile(helmPath, []byte(`#!/bin/sh
+if [ "$1" != "template" ]; then
+  echo "unexpected command $1" >&2
+  exit 1
+fi
+echo "# $@"
+echo "kind: Service"
+echo "---"
+echo "kind: StatefulSet"
+echo "rendering" >&2
+`), 0o755)
+	assert.NoError(t, err)
+
+	stdout := &bytes.Buffer{}
+	helmClient := NewClientWithStreams(helmPath, clientcmdapi.NewConfig(), stdout, &bytes.Buffer{})
+	manifests, err := helmClient.Template(context.Background(), "test", "default", UpgradeOptions{
+		Path:    "./vcluster.tgz",
+		Values:  "sync: {}\n",
+		Wait:    true,
+		Atomic:  true,
+		Force:   true,
+		Timeout: 5 * time.Minute,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, stdout.String())
+
+	rendered := string(manifests)
+	assert.Contains(t, rendered, "kind: Service\n")
+	assert.Contains(t, rendered, "kind: StatefulSet\n")
+	assert.NotContains(t, rendered, "rendering")
+	assert.Contains(t, rendered, "# template test ./vcluster.tgz")
+	assert.Contains(t, rendered, "--values ")
+	for _, flag := range []string{"--wait", "--atomic", "--force", "--timeout", "--install"} {
+		assert.NotContains(t, rendered, flag)
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	helmPath := filepath.Join(t.TempDir(), "helm")
+	err := os.WriteFile(helmPath, []byte("#!/bin/sh\necho partial\necho \"Error: values don't meet the schema\" >&2\nexit 1\n"), 0o755)
+	assert.NoError(t, err)
+
+	helmClient := NewClientWithStreams(helmPath, clientcmdapi.NewConfig(), &bytes.Buffer{}, &bytes.Buffer{})
+	manifests, err := helmClient.Template(context.Background(), "test", "default", UpgradeOptions{Path: "./vcluster.tgz"})
+	assert.Nil(t, manifests)
+	assert.EqualError(t, err, "error executing helm template: Error: values don't meet the schema\n")
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := []string{"upgrade", "test", "vcluster", "--repo", "https://charts.example.com", "--username", "user", "--password", "secret", "--password=inline", "--namespace", "default"}
+	redacted := redactArgs(args)
+	assert.Equal(t, []string{"upgrade", "test", "vcluster", "--repo", "https://charts.example.com", "--username", "user", "--password", "REDACTED", "--password=REDACTED", "--namespace", "default"}, redacted)
+	assert.NotContains(t, strings.Join(redacted, " "), "secret")
+	assert.NotContains(t, strings.Join(redacted, " "), "inline")
+
+	// the original arguments are passed to helm unchanged
+	assert.Equal(t, "secret", args[8])
+	assert.Equal(t, []string{"template", "--password"}, redactArgs([]string{"template", "--password"}))
+}
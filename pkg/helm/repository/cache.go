@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+)
+
+type cachedIndex struct {
+	etag      string
+	fetchedAt time.Time
+	charts    []helm.Chart
+}
+
+var (
+	indexCacheMutex sync.Mutex
+	indexCache      = map[string]*cachedIndex{}
+)
+
+// ParseRepositoryCached works like ParseRepository, but caches the parsed index by repository url.
+// Within the ttl the cached charts are returned without a request, afterwards the index is requested
+// conditionally and the cached charts are reused if the repository reports the index as not modified.
+func ParseRepositoryCached(ctx context.Context, repository *Definition, ttl time.Duration) ([]helm.Chart, error) {
+	indexURL := repositoryIndexURL(repository)
+
+	indexCacheMutex.Lock()
+	cached := indexCache[repository.URL]
+	indexCacheMutex.Unlock()
+	if cached != nil && time.Since(cached.fetchedAt) < ttl {
+		return withRepository(cached.charts, repository), nil
+	}
+
+	header := http.Header{}
+	if cached != nil && cached.etag != "" {
+		header.Set("If-None-Match", cached.etag)
+	}
+	client, err := newIndexClient(repository)
+	if err != nil {
+		return nil, fmt.Errorf("skipping repo %s: %w", repository.Name, err)
+	}
+	resp, err := newRequestWithHeader(ctx, client, indexURL, repository.Username, repository.Password, header)
+	if err != nil {
+		return nil, fmt.Errorf("skipping repo %s, because of error retrieving app store repository index %s: %w", repository.Name, indexURL, err)
+	}
+	defer resp.Body.Close()
+
+	// the index didn't change, so we can reuse the parsed charts
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		indexCacheMutex.Lock()
+		indexCache[repository.URL] = &cachedIndex{etag: cached.etag, fetchedAt: time.Now(), charts: cached.charts}
+		indexCacheMutex.Unlock()
+		return withRepository(cached.charts, repository), nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("skipping repo %s, because of unexpected status code %d retrieving app store repository index %s", repository.Name, resp.StatusCode, indexURL)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("skipping repo %s, because of error retrieving app store repository index %s: %w", repository.Name, indexURL, err)
+	}
+
+	charts, err := parseIndex(repository, indexURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	indexCacheMutex.Lock()
+	indexCache[repository.URL] = &cachedIndex{etag: resp.Header.Get("ETag"), fetchedAt: time.Now(), charts: charts}
+	indexCacheMutex.Unlock()
+	return withRepository(charts, repository), nil
+}
+
+// withRepository returns a copy of the charts that references the given repository, as the cache
+// is shared between definitions with the same url
+func withRepository(charts []helm.Chart, repository *Definition) []helm.Chart {
+	out := make([]helm.Chart, 0, len(charts))
+	for _, chart := range charts {
+		chart.Repository = chartRepository(repository)
+		chart.Versions = append([]string{}, chart.Versions...)
+		chart.VersionsMeta = append([]helm.Metadata{}, chart.VersionsMeta...)
+		out = append(out, chart)
+	}
+
+	return out
+}
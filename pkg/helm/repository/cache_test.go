@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIndex = `apiVersion: v1
+entries:
+  vcluster:
+  - name: vcluster
+    version: 0.22.1
+  - name: vcluster
+    version: 0.21.0
+`
+
+func TestParseRepositoryCached(t *testing.T) {
+	requests := 0
+	notModified := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	repository := &Definition{Name: "loft", URL: server.URL}
+	charts, err := ParseRepositoryCached(context.Background(), repository, time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, charts, 1)
+	assert.Equal(t, []string{"0.22.1", "0.21.0"}, charts[0].Versions)
+
+	// within the ttl the cache is used without a request
+	charts, err = ParseRepositoryCached(context.Background(), repository, time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, charts, 1)
+	assert.Equal(t, 1, requests)
+
+	// after the ttl the index is requested conditionally, the 304 response has no body to parse
+	charts, err = ParseRepositoryCached(context.Background(), repository, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+	if assert.Len(t, charts, 1) {
+		assert.Equal(t, "0.22.1", charts[0].Metadata.Version)
+		assert.Equal(t, "loft", charts[0].Repository.Name)
+	}
+}
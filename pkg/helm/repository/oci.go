@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+)
+
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	helmChartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+)
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers,omitempty"`
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+type ociToken struct {
+	Token       string `json:"token,omitempty"`
+	AccessToken string `json:"access_token,omitempty"`
+}
+
+// pullOCIChart pulls the chart archive of an oci:// repository via the OCI distribution API and
+// returns the verified archive
+func pullOCIChart(ctx context.Context, client *http.Client, helmChart *helm.Chart) ([]byte, error) {
+	reference := strings.TrimSuffix(strings.TrimPrefix(helmChart.Repository.URL, "oci://"), "/") + "/" + helmChart.Metadata.Name
+	registry, repository, found := strings.Cut(reference, "/")
+	if !found || registry == "" {
+		return nil, fmt.Errorf("invalid oci repository %s", helmChart.Repository.URL)
+	}
+	baseURL := "https://" + registry + "/v2/" + repository
+
+	registryClient := &ociClient{
+		client:   client,
+		username: helmChart.Repository.Username,
+		password: helmChart.Repository.Password,
+	}
+	body, err := registryClient.get(ctx, baseURL+"/manifests/"+helmChart.Metadata.Version, ociManifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest of oci chart %s:%s: %w", reference, helmChart.Metadata.Version, err)
+	}
+	manifest := &ociManifest{}
+	err = json.Unmarshal(body, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest of oci chart %s:%s: %w", reference, helmChart.Metadata.Version, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != helmChartLayerMediaType {
+			continue
+		}
+
+		archive, err := registryClient.get(ctx, baseURL+"/blobs/"+layer.Digest, "")
+		if err != nil {
+			return nil, fmt.Errorf("get oci chart %s:%s: %w", reference, helmChart.Metadata.Version, err)
+		}
+		digest := sha256.Sum256(archive)
+		if "sha256:"+hex.EncodeToString(digest[:]) != layer.Digest {
+			return nil, fmt.Errorf("oci chart %s:%s doesn't match digest %s", reference, helmChart.Metadata.Version, layer.Digest)
+		}
+
+		return archive, nil
+	}
+
+	return nil, fmt.Errorf("oci artifact %s:%s is no helm chart", reference, helmChart.Metadata.Version)
+}
+
+// ociClient requests registry resources and authenticates with the basic credentials of the
+// repository, registries that answer with a bearer challenge are sent a token instead
+type ociClient struct {
+	client   *http.Client
+	username string
+	password string
+	token    string
+}
+
+func (o *ociClient) get(ctx context.Context, resource, accept string) ([]byte, error) {
+	resp, err := o.request(ctx, resource, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && o.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			return nil, fmt.Errorf("unauthorized")
+		}
+
+		o.token, err = o.fetchToken(ctx, challenge)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = o.request(ctx, resource, accept)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (o *ociClient) request(ctx context.Context, resource, accept string) (*http.Response, error) {
+	header := http.Header{}
+	if accept != "" {
+		header.Set("Accept", accept)
+	}
+	if o.token != "" {
+		header.Set("Authorization", "Bearer "+o.token)
+		return newRequestWithHeader(ctx, o.client, resource, "", "", header)
+	}
+
+	return newRequestWithHeader(ctx, o.client, resource, o.username, o.password, header)
+}
+
+// fetchToken requests a token from the realm of the bearer challenge
+func (o *ociClient) fetchToken(ctx context.Context, challenge string) (string, error) {
+	params := parseChallenge(challenge[len("bearer "):])
+	if params["realm"] == "" {
+		return "", fmt.Errorf("bearer challenge without realm")
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("parse token realm: %w", err)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	resp, err := newRequest(ctx, o.client, realm.String(), o.username, o.password)
+	if err != nil {
+		return "", fmt.Errorf("fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch registry token: unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("fetch registry token: %w", err)
+	}
+	token := &ociToken{}
+	err = json.Unmarshal(body, token)
+	if err != nil {
+		return "", fmt.Errorf("parse registry token: %w", err)
+	} else if token.Token != "" {
+		return token.Token, nil
+	} else if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("registry returned no token")
+}
+
+// parseChallenge parses the comma separated key="value" parameters of a WWW-Authenticate challenge
+func parseChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	for challenge != "" {
+		key, rest, found := strings.Cut(strings.TrimLeft(challenge, ", "), "=")
+		if !found {
+			break
+		}
+
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+		challenge = rest
+	}
+
+	return params
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+)
+
+// newOCIRegistry returns a registry that serves the chart archive as vcluster:0.22.1 below
+// charts/ and requires a bearer token that is only handed out for the given credentials
+func newOCIRegistry(t *testing.T, archive []byte, digest string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "secret" || r.URL.Query().Get("scope") != "repository:charts/vcluster:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"token": "test-token"}`))
+			return
+		} else if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:charts/vcluster:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/charts/vcluster/manifests/0.22.1":
+			assert.Equal(t, ociManifestMediaType, r.Header.Get("Accept"))
+			_, _ = w.Write([]byte(fmt.Sprintf(`{
+  "schemaVersion": 2,
+  "layers": [
+    {"mediaType": "application/vnd.cncf.helm.chart.provenance.v1.prov", "digest": "sha256:0000"},
+    {"mediaType": "%s", "digest": "%s"}
+  ]
+}`, helmChartLayerMediaType, digest)))
+		case "/v2/charts/vcluster/blobs/" + digest:
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func TestParseReadmeValuesOCI(t *testing.T) {
+	archive := &bytes.Buffer{}
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"vcluster/Chart.yaml":  "name: vcluster\n",
+		"vcluster/README.md":   "# vcluster\n",
+		"vcluster/values.yaml": "sync: {}\n",
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	sum := sha256.Sum256(archive.Bytes())
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	server := newOCIRegistry(t, archive.Bytes(), digest)
+	defer server.Close()
+
+	ociChart := func(username, password string) *helm.Chart {
+		return &helm.Chart{
+			Metadata: helm.Metadata{Name: "vcluster", Version: "0.22.1"},
+			Repository: helm.ChartRepository{
+				URL:      "oci://" + strings.TrimPrefix(server.URL, "https://") + "/charts",
+				Username: username,
+				Password: password,
+				Insecure: true,
+			},
+		}
+	}
+
+	readme, values, err := ParseReadmeValues(context.Background(), ociChart("user", "secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "# vcluster\n", readme)
+	assert.Equal(t, "sync: {}\n", values)
+
+	_, _, err = ParseReadmeValues(context.Background(), ociChart("user", "wrong"))
+	assert.ErrorContains(t, err, "fetch registry token")
+}
+
+func TestPullOCIChartDigestMismatch(t *testing.T) {
+	server := newOCIRegistry(t, []byte("tampered"), "sha256:"+strings.Repeat("0", 64))
+	defer server.Close()
+
+	_, _, err := ParseReadmeValues(context.Background(), &helm.Chart{
+		Metadata: helm.Metadata{Name: "vcluster", Version: "0.22.1"},
+		Repository: helm.ChartRepository{
+			URL:      "oci://" + strings.TrimPrefix(server.URL, "https://") + "/charts",
+			Username: "user",
+			Password: "secret",
+			Insecure: true,
+		},
+	})
+	assert.ErrorContains(t, err, "doesn't match digest")
+}
+
+func TestParseChallenge(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:loft-sh/vcluster:pull,push",
+	}, parseChallenge(`realm="https://ghcr.io/token",service="ghcr.io",scope="repository:loft-sh/vcluster:pull,push"`))
+}
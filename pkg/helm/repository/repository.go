@@ -6,11 +6,15 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ghodss/yaml"
@@ -32,18 +36,81 @@ type Definition struct {
 	URL      string `json:"url,omitempty"`
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
-	Insecure bool   `json:"insecure,omitempty"`
+	// Insecure skips the TLS verification of the repository
+	Insecure bool `json:"insecure,omitempty"`
+	// CABundle holds PEM encoded certificates that are trusted in addition to the system certificates
+	CABundle []byte `json:"caBundle,omitempty"`
+	// ClientCert is the PEM encoded client certificate that is used to authenticate against the repository
+	ClientCert []byte `json:"clientCert,omitempty"`
+	// ClientKey is the PEM encoded key of the client certificate
+	ClientKey []byte `json:"clientKey,omitempty"`
+	// Proxy is the url of the proxy that is used for the repository, if empty the proxy is taken
+	// from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy string `json:"proxy,omitempty"`
 }
 
 func ParseReadmeValues(ctx context.Context, helmChart *helm.Chart) (string, string, error) {
-	if len(helmChart.Metadata.Urls) == 0 {
-		return "", "", nil
+	files, err := extractChartFiles(ctx, helmChart, "README.md", "values.yaml")
+	if err != nil {
+		return "", "", err
+	}
+
+	return files["README.md"], files["values.yaml"], nil
+}
+
+// ParseValuesSchema returns the values.schema.json of the chart or an empty string if the chart has none
+func ParseValuesSchema(ctx context.Context, helmChart *helm.Chart) (string, error) {
+	files, err := extractChartFiles(ctx, helmChart, "values.schema.json")
+	if err != nil {
+		return "", err
+	}
+
+	return files["values.schema.json"], nil
+}
+
+// ParseChartMetadata returns the metadata of the Chart.yaml in the gzipped chart archive
+func ParseChartMetadata(archive io.Reader) (*helm.Metadata, error) {
+	files, err := extractFiles(archive, []string{"Chart.yaml"})
+	if err != nil {
+		return nil, err
+	} else if files["Chart.yaml"] == "" {
+		return nil, fmt.Errorf("chart archive has no Chart.yaml")
 	}
 
+	metadata := &helm.Metadata{}
+	err = yaml.Unmarshal([]byte(files["Chart.yaml"]), metadata)
+	if err != nil {
+		return nil, fmt.Errorf("parse Chart.yaml: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// extractChartFiles downloads the chart archive and returns the content of the given files of the chart
+// root, files that don't exist in the chart are missing in the result
+func extractChartFiles(ctx context.Context, helmChart *helm.Chart, names ...string) (map[string]string, error) {
+	tlsConfig, err := newTLSConfig(helmChart.Repository.CABundle, helmChart.Repository.ClientCert, helmChart.Repository.ClientKey, helmChart.Repository.Insecure)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(tlsConfig, helmChart.Repository.Proxy)
+	if err != nil {
+		return nil, err
+	}
 	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		Transport: transport,
+	}
+
+	// oci charts have no urls and are pulled from the registry instead
+	if strings.HasPrefix(helmChart.Repository.URL, "oci://") {
+		archive, err := pullOCIChart(ctx, client, helmChart)
+		if err != nil {
+			return nil, err
+		}
+
+		return extractFiles(bytes.NewReader(archive), names)
+	} else if len(helmChart.Metadata.Urls) == 0 {
+		return map[string]string{}, nil
 	}
 
 	url := helmChart.Metadata.Urls[0]
@@ -53,90 +120,212 @@ func ParseReadmeValues(ctx context.Context, helmChart *helm.Chart) (string, stri
 
 	resp, err := newRequest(ctx, client, url, helmChart.Repository.Username, helmChart.Repository.Password)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	uncompressedStream, err := gzip.NewReader(resp.Body)
+	return extractFiles(resp.Body, names)
+}
+
+// extractFiles returns the content of the given files of the chart root from the gzipped chart archive
+func extractFiles(archive io.Reader, names []string) (map[string]string, error) {
+	files := map[string]string{}
+	uncompressedStream, err := gzip.NewReader(archive)
 	if err != nil {
-		return "", "", errors.Wrap(err, "read gzip")
+		return nil, errors.Wrap(err, "read gzip")
 	}
 
-	var (
-		readme = ""
-		values = ""
-	)
-
 	tarReader := tar.NewReader(uncompressedStream)
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return "", "", fmt.Errorf("extract: Next() failed: %s", err.Error())
+			return nil, fmt.Errorf("extract: Next() failed: %s", err.Error())
 		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
 			continue
 		case tar.TypeReg:
-			splitted := strings.Split(header.Name, "/")
-			if splitted[0] == "README.md" || (len(splitted) > 1 && splitted[1] == "README.md") {
-				buffer := &bytes.Buffer{}
-				_, err := io.Copy(buffer, tarReader)
-				if err != nil {
-					return "", "", fmt.Errorf("extract: error reading README.md: %v", err.Error())
-				}
-
-				readme = buffer.String()
-				if values != "" {
-					return readme, values, nil
+			name := chartFileName(header.Name, names)
+			if name == "" {
+				if _, err := io.Copy(io.Discard, tarReader); err != nil {
+					return nil, fmt.Errorf("extract: Copy() failed: %s", err.Error())
 				}
 				continue
 			}
-			if splitted[0] == "values.yaml" || (len(splitted) > 1 && splitted[1] == "values.yaml") {
-				buffer := &bytes.Buffer{}
-				_, err := io.Copy(buffer, tarReader)
-				if err != nil {
-					return "", "", fmt.Errorf("extract: error reading values.yaml: %v", err.Error())
-				}
 
-				values = buffer.String()
-				if readme != "" {
-					return readme, values, nil
-				}
-				continue
+			buffer := &bytes.Buffer{}
+			_, err := io.Copy(buffer, tarReader)
+			if err != nil {
+				return nil, fmt.Errorf("extract: error reading %s: %v", name, err.Error())
 			}
-			if _, err := io.Copy(io.Discard, tarReader); err != nil {
-				return "", "", fmt.Errorf("extract: Copy() failed: %s", err.Error())
+
+			files[name] = buffer.String()
+			if len(files) == len(names) {
+				return files, nil
 			}
 		default:
-			return "", "", fmt.Errorf("extract: uknown type: %v in %s", header.Typeflag, header.Name)
+			return nil, fmt.Errorf("extract: uknown type: %v in %s", header.Typeflag, header.Name)
+		}
+	}
+
+	return files, nil
+}
+
+// chartFileName returns which of the names the archive entry is, archives either contain the files
+// directly or in a directory named after the chart
+func chartFileName(entry string, names []string) string {
+	splitted := strings.Split(entry, "/")
+	for _, name := range names {
+		if splitted[0] == name || (len(splitted) > 1 && splitted[1] == name) {
+			return name
 		}
 	}
 
-	return readme, values, nil
+	return ""
 }
 
 func ParseRepository(ctx context.Context, repository *Definition) ([]helm.Chart, error) {
-	indexURL := strings.Join([]string{strings.TrimRight(repository.URL, "/"), "index.yaml"}, "/")
-	body, err := Get(ctx, &http.Client{
-		Timeout: time.Second * 20,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	}, indexURL, repository.Username, repository.Password)
+	indexURL := repositoryIndexURL(repository)
+	client, err := newIndexClient(repository)
+	if err != nil {
+		return nil, fmt.Errorf("skipping repo %s: %w", repository.Name, err)
+	}
+
+	body, err := Get(ctx, client, indexURL, repository.Username, repository.Password)
 	if err != nil {
 		return nil, fmt.Errorf("skipping repo %s, because of error retrieving app store repository index %s: %w", repository.Name, indexURL, err)
 	}
 
+	return parseIndex(repository, indexURL, body)
+}
+
+// ParseRepositories parses the repositories with at most concurrency indexes fetched at the same time.
+// Repositories that fail are skipped and their errors returned, the charts and errors are ordered
+// by repository name.
+func ParseRepositories(ctx context.Context, repositories []*Definition, concurrency int) ([]helm.Chart, []error) {
+	sorted := append([]*Definition{}, repositories...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	charts := make([][]helm.Chart, len(sorted))
+	errs := make([]error, len(sorted))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency && w < len(sorted); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				charts[i], errs[i] = ParseRepository(ctx, sorted[i])
+			}
+		}()
+	}
+	for i := range sorted {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	allCharts := []helm.Chart{}
+	allErrs := []error{}
+	for i := range sorted {
+		if errs[i] != nil {
+			allErrs = append(allErrs, errs[i])
+			continue
+		}
+
+		allCharts = append(allCharts, charts[i]...)
+	}
+
+	return allCharts, allErrs
+}
+
+func repositoryIndexURL(repository *Definition) string {
+	return strings.Join([]string{strings.TrimRight(repository.URL, "/"), "index.yaml"}, "/")
+}
+
+func newIndexClient(repository *Definition) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(repository.CABundle, repository.ClientCert, repository.ClientKey, repository.Insecure)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(tlsConfig, repository.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout:   time.Second * 20,
+		Transport: transport,
+	}, nil
+}
+
+// newTransport routes the requests through the given proxy or, if no proxy is given, through the
+// proxy of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func newTransport(tlsConfig *tls.Config, proxy string) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url: %w", err)
+		} else if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %s", proxy)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+// newTLSConfig trusts the system certificates and the given CA bundle, the verification is
+// only skipped if the repository is explicitly marked as insecure
+func newTLSConfig(caBundle, clientCert, clientKey []byte, insecure bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecure,
+	}
+	if len(caBundle) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("ca bundle doesn't contain any valid PEM encoded certificate")
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+	if len(clientCert) > 0 || len(clientKey) > 0 {
+		certificate, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("parse client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func parseIndex(repository *Definition, indexURL string, body []byte) ([]helm.Chart, error) {
 	entries := &Entries{}
-	err = yaml.Unmarshal(body, entries)
+	err := yaml.Unmarshal(body, entries)
 	if err != nil {
 		return nil, fmt.Errorf("skipping repo %s, because of error parsing app store repository index %s: %w", repository.Name, indexURL, err)
 	}
 
-	// we only add the latest version to avoid huge files
+	// the latest version is used as chart metadata, all versions are kept in VersionsMeta
 	charts := []helm.Chart{}
 	for _, metadatas := range entries.Entries {
 		if len(metadatas) == 0 {
@@ -144,33 +333,56 @@ func ParseRepository(ctx context.Context, repository *Definition) ([]helm.Chart,
 		}
 
 		chart := helm.Chart{
-			Metadata: *metadatas[0],
-			Repository: helm.ChartRepository{
-				Name:     repository.Name,
-				URL:      repository.URL,
-				Username: repository.Username,
-				Password: repository.Password,
-				Insecure: repository.Insecure,
-			},
-			Versions: []string{},
+			Metadata:     *metadatas[0],
+			Repository:   chartRepository(repository),
+			Versions:     []string{},
+			VersionsMeta: []helm.Metadata{},
 		}
 
 		// add versions
 		for _, meta := range metadatas {
 			chart.Versions = append(chart.Versions, meta.Version)
+			chart.VersionsMeta = append(chart.VersionsMeta, *meta)
 		}
 
 		charts = append(charts, chart)
 	}
 
+	// the index entries are a map, so the charts are sorted to return them in a stable order
+	sort.Slice(charts, func(i, j int) bool {
+		return charts[i].Metadata.Name < charts[j].Metadata.Name
+	})
 	return charts, nil
 }
 
+func chartRepository(repository *Definition) helm.ChartRepository {
+	return helm.ChartRepository{
+		Name:       repository.Name,
+		URL:        repository.URL,
+		Username:   repository.Username,
+		Password:   repository.Password,
+		Insecure:   repository.Insecure,
+		CABundle:   repository.CABundle,
+		ClientCert: repository.ClientCert,
+		ClientKey:  repository.ClientKey,
+		Proxy:      repository.Proxy,
+	}
+}
+
 func newRequest(ctx context.Context, client *http.Client, url, username, password string) (*http.Response, error) {
+	return newRequestWithHeader(ctx, client, url, username, password, nil)
+}
+
+func newRequestWithHeader(ctx context.Context, client *http.Client, url, username, password string, header http.Header) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 
 	if username != "" && password != "" {
 		if strings.HasPrefix(username, "$") {
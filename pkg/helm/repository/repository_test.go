@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
+)
+
+func TestParseRepositoryVersionsMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  vcluster:
+  - name: vcluster
+    version: 0.22.1
+    appVersion: 0.22.1
+    kubeVersion: ">=1.26.0-0"
+  - name: vcluster
+    version: 0.15.0
+    appVersion: 0.15.0
+    kubeVersion: ">=1.22.0-0"
+`))
+	}))
+	defer server.Close()
+
+	charts, err := ParseRepository(context.Background(), &Definition{Name: "loft", URL: server.URL})
+	assert.NoError(t, err)
+	if !assert.Len(t, charts, 1) {
+		return
+	}
+
+	assert.Equal(t, "0.22.1", charts[0].Metadata.Version)
+	assert.Equal(t, []string{"0.22.1", "0.15.0"}, charts[0].Versions)
+	if assert.Len(t, charts[0].VersionsMeta, 2) {
+		assert.Equal(t, "0.22.1", charts[0].VersionsMeta[0].AppVersion)
+		assert.Equal(t, ">=1.26.0-0", charts[0].VersionsMeta[0].KubeVersion)
+		assert.Equal(t, "0.15.0", charts[0].VersionsMeta[1].AppVersion)
+		assert.Equal(t, ">=1.22.0-0", charts[0].VersionsMeta[1].KubeVersion)
+	}
+}
+
+func TestParseRepositoryCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	// the self signed certificate of the server is not trusted by default
+	_, err := ParseRepository(context.Background(), &Definition{Name: "loft", URL: server.URL})
+	assert.Error(t, err)
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	charts, err := ParseRepository(context.Background(), &Definition{Name: "loft", URL: server.URL, CABundle: caBundle})
+	assert.NoError(t, err)
+	assert.Len(t, charts, 1)
+
+	charts, err = ParseRepository(context.Background(), &Definition{Name: "loft", URL: server.URL, Insecure: true})
+	assert.NoError(t, err)
+	assert.Len(t, charts, 1)
+
+	_, err = ParseRepository(context.Background(), &Definition{Name: "loft", URL: server.URL, CABundle: []byte("invalid")})
+	assert.Error(t, err)
+}
+
+func TestParseValuesSchema(t *testing.T) {
+	archive := &bytes.Buffer{}
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"vcluster/Chart.yaml":         "name: vcluster\n",
+		"vcluster/values.schema.json": `{"type": "object"}`,
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/charts/vcluster-0.22.1.tgz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	schema, err := ParseValuesSchema(context.Background(), &helm.Chart{
+		Metadata:   helm.Metadata{Name: "vcluster", Version: "0.22.1", Urls: []string{"charts/vcluster-0.22.1.tgz"}},
+		Repository: helm.ChartRepository{URL: server.URL},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type": "object"}`, schema)
+
+	// files that are missing in the archive are empty, absolute chart urls are used as is
+	readme, values, err := ParseReadmeValues(context.Background(), &helm.Chart{
+		Metadata:   helm.Metadata{Name: "vcluster", Version: "0.22.1", Urls: []string{server.URL + "/charts/vcluster-0.22.1.tgz"}},
+		Repository: helm.ChartRepository{URL: server.URL},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, readme)
+	assert.Empty(t, values)
+}
+
+func TestParseRepositoryProxy(t *testing.T) {
+	// the repository url is never resolved, all requests have to go through the proxy
+	requestsMutex := sync.Mutex{}
+	requests := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsMutex.Lock()
+		requests = append(requests, r.URL.String())
+		requestsMutex.Unlock()
+
+		_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  vcluster:
+  - name: vcluster
+    version: 0.22.1
+`))
+	}))
+	defer proxy.Close()
+
+	charts, err := ParseRepository(context.Background(), &Definition{Name: "loft", URL: "http://charts.example.invalid", Proxy: proxy.URL})
+	assert.NoError(t, err)
+	assert.Len(t, charts, 1)
+	assert.Equal(t, proxy.URL, charts[0].Repository.Proxy)
+	assert.Equal(t, []string{"http://charts.example.invalid/index.yaml"}, requests)
+
+	_, err = ParseRepository(context.Background(), &Definition{Name: "loft", URL: "http://charts.example.invalid", Proxy: "proxy:3128"})
+	assert.Error(t, err)
+}
+
+func TestParseValuesSchemaProxy(t *testing.T) {
+	archive := &bytes.Buffer{}
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+	content := `{"type": "object"}`
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "vcluster/values.schema.json", Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	requests := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		_, _ = w.Write(archive.Bytes())
+	}))
+	defer proxy.Close()
+
+	schema, err := ParseValuesSchema(context.Background(), &helm.Chart{
+		Metadata:   helm.Metadata{Name: "vcluster", Version: "0.22.1", Urls: []string{"charts/vcluster-0.22.1.tgz"}},
+		Repository: helm.ChartRepository{URL: "http://charts.example.invalid", Proxy: proxy.URL},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, content, schema)
+	assert.Equal(t, []string{"http://charts.example.invalid/charts/vcluster-0.22.1.tgz"}, requests)
+}
+
+func TestParseRepositories(t *testing.T) {
+	newRepository := func(charts ...string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			index := "apiVersion: v1\nentries:\n"
+			for _, chart := range charts {
+				index += "  " + chart + ":\n  - name: " + chart + "\n    version: 1.0.0\n"
+			}
+			_, _ = w.Write([]byte(index))
+		}))
+	}
+	loft := newRepository("vcluster", "loft")
+	defer loft.Close()
+	bitnami := newRepository("nginx")
+	defer bitnami.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("entries: ["))
+	}))
+	defer broken.Close()
+
+	charts, errs := ParseRepositories(context.Background(), []*Definition{
+		{Name: "loft", URL: loft.URL},
+		{Name: "broken", URL: broken.URL},
+		{Name: "bitnami", URL: bitnami.URL},
+	}, 2)
+	if assert.Len(t, errs, 1) {
+		assert.ErrorContains(t, errs[0], "skipping repo broken")
+	}
+
+	names := []string{}
+	for _, chart := range charts {
+		names = append(names, chart.Repository.Name+"/"+chart.Metadata.Name)
+	}
+	assert.Equal(t, []string{"bitnami/nginx", "loft/loft", "loft/vcluster"}, names)
+}
+
+func TestParseChartMetadata(t *testing.T) {
+	archive := &bytes.Buffer{}
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"vcluster/Chart.yaml":                "name: vcluster\nversion: 0.22.1\nkubeVersion: \">=1.26.0-0\"\n",
+		"vcluster/charts/etcd/Chart.yaml":    "name: etcd\nversion: 1.0.0\n",
+		"vcluster/templates/deployment.yaml": "kind: Deployment\n",
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	metadata, err := ParseChartMetadata(archive)
+	assert.NoError(t, err)
+	assert.Equal(t, "vcluster", metadata.Name)
+	assert.Equal(t, "0.22.1", metadata.Version)
+	assert.Equal(t, ">=1.26.0-0", metadata.KubeVersion)
+
+	_, err = ParseChartMetadata(bytes.NewBufferString("chart"))
+	assert.Error(t, err)
+}
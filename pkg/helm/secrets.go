@@ -22,6 +22,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
+	"slices"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +34,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	klog "k8s.io/klog/v2"
 	client2 "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/constants"
 )
 
 var b64 = base64.StdEncoding
@@ -53,6 +58,8 @@ type Release struct {
 	Version int `json:"version,omitempty"`
 	// Namespace is the kubernetes namespace of the release.
 	Namespace string `json:"namespace,omitempty"`
+	// Hooks are all of the hooks declared for this release.
+	Hooks []*Hook `json:"hooks,omitempty"`
 
 	Secret *corev1.Secret `json:"-"`
 }
@@ -79,6 +86,53 @@ type Info struct {
 	Notes string `json:"notes,omitempty"`
 }
 
+const (
+	// StatusDeployed indicates that the release has been pushed to Kubernetes
+	StatusDeployed = "deployed"
+	// StatusSuperseded indicates that this release object is outdated and a newer one exists
+	StatusSuperseded = "superseded"
+	// StatusFailed indicates that the release was not successfully deployed
+	StatusFailed = "failed"
+)
+
+// Hook defines a hook object.
+type Hook struct {
+	// Name is the name of the hook resource
+	Name string `json:"name,omitempty"`
+	// Kind is the Kubernetes kind.
+	Kind string `json:"kind,omitempty"`
+	// Path is the chart-relative path to the template.
+	Path string `json:"path,omitempty"`
+	// Events are the events that this hook fires on.
+	Events []string `json:"events,omitempty"`
+	// LastRun indicates the date/time this was last run.
+	LastRun HookExecution `json:"last_run,omitempty"`
+}
+
+// HookExecution records the result for the last execution of a hook for a given release.
+type HookExecution struct {
+	// StartedAt indicates the date/time this hook was started
+	StartedAt Time `json:"started_at,omitempty"`
+	// CompletedAt indicates the date/time this hook was completed.
+	CompletedAt Time `json:"completed_at,omitempty"`
+	// Phase indicates whether the hook completed successfully
+	Phase HookPhase `json:"phase"`
+}
+
+// HookPhase represents the current phase of a hook execution
+type HookPhase string
+
+const (
+	// HookPhaseUnknown indicates that a hook is in an unknown state
+	HookPhaseUnknown HookPhase = "Unknown"
+	// HookPhaseRunning indicates that a hook is currently executing
+	HookPhaseRunning HookPhase = "Running"
+	// HookPhaseSucceeded indicates that hook execution succeeded
+	HookPhaseSucceeded HookPhase = "Succeeded"
+	// HookPhaseFailed indicates that hook execution failed
+	HookPhaseFailed HookPhase = "Failed"
+)
+
 // Chart holds the chart metadata
 type MetadataChart struct {
 	Metadata *Metadata `json:"metadata,omitempty"`
@@ -116,6 +170,45 @@ func (secrets *Secrets) Update(ctx context.Context, secret *corev1.Secret) (*cor
 	return secret, err
 }
 
+// reservedLabels are the labels helm uses to find and order its release secrets
+var reservedLabels = map[string]bool{
+	"name":       true,
+	"owner":      true,
+	"status":     true,
+	"version":    true,
+	"createdAt":  true,
+	"modifiedAt": true,
+}
+
+// AddLabels adds the labels to the secret of the latest revision of the release. The labels that helm
+// uses to find its releases are not changed.
+func (secrets *Secrets) AddLabels(ctx context.Context, name string, namespace string, labels map[string]string) error {
+	release, err := secrets.Get(ctx, name, namespace)
+	if err != nil {
+		return err
+	}
+
+	secret := release.Secret
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	changed := false
+	for key, value := range labels {
+		if reservedLabels[key] || secret.Labels[key] == value {
+			continue
+		}
+
+		secret.Labels[key] = value
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	_, err = secrets.Update(ctx, secret)
+	return err
+}
+
 // List fetches all releases and returns the list releases such
 // that filter(release) == true. An error is returned if the
 // secret fails to retrieve the releases.
@@ -189,10 +282,99 @@ func (secrets *Secrets) Get(ctx context.Context, name string, namespace string)
 	return latest, nil
 }
 
+// History returns all revisions of the release sorted ascending by version.
+// An error is returned if the secrets fail to retrieve the releases.
+func (secrets *Secrets) History(ctx context.Context, name string, namespace string) ([]*Release, error) {
+	ls := kblabels.Set{}
+	ls["name"] = name
+	list, err := secrets.List(ctx, ls.AsSelector(), namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// LastDeployedRevision returns the highest revision of the release with status deployed. A NotFound
+// error is returned if the release has no deployed revision, e.g. because its first install failed.
+func (secrets *Secrets) LastDeployedRevision(ctx context.Context, name string, namespace string) (int, error) {
+	history, err := secrets.History(ctx, name, namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Info != nil && history[i].Info.Status == StatusDeployed {
+			return history[i].Version, nil
+		}
+	}
+
+	return 0, kerrors.NewNotFound(corev1.Resource("Secret"), name)
+}
+
+// VClusterChartNames are the names of the charts that deploy a vcluster
+var VClusterChartNames = []string{"vcluster", "vcluster-k8s", "vcluster-k0s", "vcluster-eks"}
+
+// ListVClusterReleases returns the latest revision of every release in the namespace that was
+// deployed with one of the vcluster charts or the configured default chart, sorted by name.
+func (secrets *Secrets) ListVClusterReleases(ctx context.Context, namespace string) ([]*Release, error) {
+	list, err := secrets.List(ctx, nil, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]*Release{}
+	for _, rls := range list {
+		chartName := rls.Chart.Metadata.Name
+		if !slices.Contains(VClusterChartNames, chartName) && chartName != path.Base(constants.DefaultVClusterChartName) {
+			continue
+		}
+
+		if existing, ok := latest[rls.Name]; !ok || existing.Version < rls.Version {
+			latest[rls.Name] = rls
+		}
+	}
+
+	releases := make([]*Release, 0, len(latest))
+	for _, rls := range latest {
+		releases = append(releases, rls)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Name < releases[j].Name
+	})
+	return releases, nil
+}
+
 // decodeRelease decodes the bytes of data into a release
 // type. Data must contain a base64 encoded gzipped string of a
 // valid release, otherwise an error is returned.
 func decodeRelease(secret *corev1.Secret, data string) (*Release, error) {
+	b, err := decodeReleaseData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var rls Release
+	// unmarshal release object bytes
+	if err := json.Unmarshal(b, &rls); err != nil {
+		// releases migrated from helm 2 are sometimes encoded twice
+		inner, innerErr := decodeReleaseData(string(bytes.TrimSpace(b)))
+		if innerErr != nil || json.Unmarshal(inner, &rls) != nil {
+			return nil, fmt.Errorf("error decoding %s: %w", string(b), err)
+		}
+
+		klog.TODO().V(4).Info("decoded doubly encoded release", "secret", secret.Name)
+	}
+
+	rls.Secret = secret
+	return &rls, nil
+}
+
+// decodeReleaseData base64 decodes the data and decompresses it if it is gzipped
+func decodeReleaseData(data string) ([]byte, error) {
 	// base64 decode string
 	b, err := b64.DecodeString(data)
 	if err != nil {
@@ -216,12 +398,5 @@ func decodeRelease(secret *corev1.Secret, data string) (*Release, error) {
 		b = b2
 	}
 
-	var rls Release
-	// unmarshal release object bytes
-	if err := json.Unmarshal(b, &rls); err != nil {
-		return nil, fmt.Errorf("error decoding %s: %w", string(b), err)
-	}
-
-	rls.Secret = secret
-	return &rls, nil
+	return b, nil
 }
@@ -0,0 +1,183 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newReleaseSecret encodes the release the same way helm stores it, optionally without compression
+func newReleaseSecret(t *testing.T, release *Release, compress bool) *corev1.Secret {
+	data, err := json.Marshal(release)
+	assert.NoError(t, err)
+
+	if compress {
+		buffer := &bytes.Buffer{}
+		writer := gzip.NewWriter(buffer)
+		_, err = writer.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		data = buffer.Bytes()
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", release.Name, release.Version),
+			Namespace: release.Namespace,
+			Labels: map[string]string{
+				"owner": "helm",
+				"name":  release.Name,
+			},
+		},
+		Data: map[string][]byte{
+			"release": []byte(b64.EncodeToString(data)),
+		},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	newRelease := func(name string, version int, status string) *Release {
+		return &Release{
+			Name:      name,
+			Namespace: "default",
+			Version:   version,
+			Info:      &Info{Status: status},
+			Chart:     &MetadataChart{Metadata: &Metadata{Name: "vcluster"}},
+		}
+	}
+
+	clientSet := fake.NewSimpleClientset(
+		newReleaseSecret(t, newRelease("test", 3, "deployed"), false),
+		newReleaseSecret(t, newRelease("test", 1, "superseded"), true),
+		newReleaseSecret(t, newRelease("test", 2, "failed"), true),
+		newReleaseSecret(t, newRelease("other", 1, "deployed"), true),
+	)
+
+	history, err := NewSecretsClientSet(clientSet).History(context.Background(), "test", "default")
+	assert.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		for i, release := range history {
+			assert.Equal(t, "test", release.Name)
+			assert.Equal(t, i+1, release.Version)
+		}
+		assert.Equal(t, "failed", history[1].Info.Status)
+		assert.Equal(t, "deployed", history[2].Info.Status)
+	}
+}
+
+func TestLastDeployedRevision(t *testing.T) {
+	newRelease := func(name string, version int, status string) *Release {
+		return &Release{
+			Name:      name,
+			Namespace: "default",
+			Version:   version,
+			Info:      &Info{Status: status},
+			Chart:     &MetadataChart{Metadata: &Metadata{Name: "vcluster"}},
+		}
+	}
+
+	secrets := NewSecretsClientSet(fake.NewSimpleClientset(
+		newReleaseSecret(t, newRelease("test", 1, "superseded"), true),
+		newReleaseSecret(t, newRelease("test", 2, "deployed"), true),
+		newReleaseSecret(t, newRelease("test", 3, "failed"), true),
+		newReleaseSecret(t, newRelease("test", 4, "failed"), false),
+		newReleaseSecret(t, newRelease("broken", 1, "failed"), true),
+	))
+
+	revision, err := secrets.LastDeployedRevision(context.Background(), "test", "default")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, revision)
+
+	_, err = secrets.LastDeployedRevision(context.Background(), "broken", "default")
+	assert.True(t, kerrors.IsNotFound(err))
+
+	_, err = secrets.LastDeployedRevision(context.Background(), "missing", "default")
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestListDoublyEncodedRelease(t *testing.T) {
+	release := &Release{
+		Name:      "test",
+		Namespace: "default",
+		Version:   1,
+		Info:      &Info{Status: "deployed"},
+		Chart:     &MetadataChart{Metadata: &Metadata{Name: "vcluster", Version: "0.22.1"}},
+	}
+	secret := newReleaseSecret(t, release, true)
+
+	// wrap the encoded release a second time
+	buffer := &bytes.Buffer{}
+	writer := gzip.NewWriter(buffer)
+	_, err := writer.Write(secret.Data["release"])
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	secret.Data["release"] = []byte(b64.EncodeToString(buffer.Bytes()))
+
+	secrets := NewSecretsClientSet(fake.NewSimpleClientset(secret))
+	decoded, err := secrets.Get(context.Background(), "test", "default")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0.22.1", decoded.Chart.Metadata.Version)
+		assert.Equal(t, "deployed", decoded.Info.Status)
+	}
+}
+
+func TestListVClusterReleases(t *testing.T) {
+	newRelease := func(name, namespace, chart string, version int) *Release {
+		return &Release{
+			Name:      name,
+			Namespace: namespace,
+			Version:   version,
+			Info:      &Info{Status: "deployed"},
+			Chart:     &MetadataChart{Metadata: &Metadata{Name: chart}},
+		}
+	}
+
+	clientSet := fake.NewSimpleClientset(
+		newReleaseSecret(t, newRelease("b", "default", "vcluster", 1), true),
+		newReleaseSecret(t, newRelease("b", "default", "vcluster", 2), true),
+		newReleaseSecret(t, newRelease("a", "default", "vcluster-k8s", 1), false),
+		newReleaseSecret(t, newRelease("nginx", "default", "nginx", 1), true),
+		newReleaseSecret(t, newRelease("c", "other", "vcluster", 1), true),
+	)
+
+	releases, err := NewSecretsClientSet(clientSet).ListVClusterReleases(context.Background(), "default")
+	assert.NoError(t, err)
+	if assert.Len(t, releases, 2) {
+		assert.Equal(t, "a", releases[0].Name)
+		assert.Equal(t, "b", releases[1].Name)
+		assert.Equal(t, 2, releases[1].Version)
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	release := &Release{
+		Name:      "test",
+		Namespace: "default",
+		Version:   1,
+		Info:      &Info{Status: "deployed"},
+		Chart:     &MetadataChart{Metadata: &Metadata{Name: "vcluster"}},
+	}
+	clientSet := fake.NewSimpleClientset(newReleaseSecret(t, release, true))
+
+	secrets := NewSecretsClientSet(clientSet)
+	err := secrets.AddLabels(context.Background(), "test", "default", map[string]string{
+		"vcluster.loft.sh/generation": "2",
+		"owner":                       "vcluster",
+	})
+	assert.NoError(t, err)
+
+	secret, err := clientSet.CoreV1().Secrets("default").Get(context.Background(), "sh.helm.release.v1.test.v1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", secret.Labels["vcluster.loft.sh/generation"])
+	assert.Equal(t, "helm", secret.Labels["owner"])
+	assert.Equal(t, "test", secret.Labels["name"])
+}
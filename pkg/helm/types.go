@@ -9,6 +9,10 @@ type Chart struct {
 	// +optional
 	Versions []string `json:"versions,omitempty"`
 
+	// VersionsMeta holds the metadata of every chart version, in the same order as Versions
+	// +optional
+	VersionsMeta []Metadata `json:"versionsMeta,omitempty"`
+
 	// Repository is the repository name of this chart
 	// +optional
 	Repository ChartRepository `json:"repository,omitempty"`
@@ -35,6 +39,26 @@ type ChartRepository struct {
 	// verification
 	// +optional
 	Insecure bool `json:"insecure,omitempty"`
+
+	// CABundle holds PEM encoded certificates that are trusted to
+	// verify the repository
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// ClientCert is the PEM encoded client certificate used to
+	// authenticate against the repository
+	// +optional
+	ClientCert []byte `json:"clientCert,omitempty"`
+
+	// ClientKey is the PEM encoded key of the client certificate
+	// +optional
+	ClientKey []byte `json:"clientKey,omitempty"`
+
+	// Proxy is the url of the proxy that is used to reach the
+	// repository instead of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables
+	// +optional
+	Proxy string `json:"proxy,omitempty"`
 }
 type Maintainer struct {
 	// Name is a user name or organization name
@@ -0,0 +1,102 @@
+package cidrdiscovery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const clusterCIDRFlag = "--cluster-cidr="
+
+var (
+	podCIDRMutex sync.Mutex
+	podCIDR      string
+)
+
+// GetPodCIDR returns the pod CIDR of the host cluster, in dual-stack clusters both CIDRs are
+// returned comma separated. It is read from the --cluster-cidr flag of the kube-controller-manager
+// if it runs as a pod, which result is cached as it doesn't change while the controller is running.
+// Otherwise the pod CIDRs of all nodes are returned, as each node only has a slice of the cluster
+// pod CIDR. An error is returned if a node has no pod CIDR, as the result would be incomplete.
+func GetPodCIDR(ctx context.Context, kubeClient kubernetes.Interface) (string, error) {
+	podCIDRMutex.Lock()
+	defer podCIDRMutex.Unlock()
+
+	if podCIDR != "" {
+		return podCIDR, nil
+	}
+
+	// the controller manager might not run as pod or not be readable, e.g. in managed clusters
+	cidr, controllerManagerErr := podCIDRFromControllerManager(ctx, kubeClient)
+	if controllerManagerErr == nil && cidr != "" {
+		podCIDR = cidr
+		return podCIDR, nil
+	}
+
+	cidr, err := podCIDRFromNodes(ctx, kubeClient)
+	if err != nil {
+		if controllerManagerErr != nil {
+			return "", fmt.Errorf("%w, %w", controllerManagerErr, err)
+		}
+		return "", err
+	}
+
+	return cidr, nil
+}
+
+func podCIDRFromControllerManager(ctx context.Context, kubeClient kubernetes.Interface) (string, error) {
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
+		LabelSelector: "component=kube-controller-manager",
+	})
+	if err != nil {
+		return "", fmt.Errorf("list kube-controller-manager pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+				if strings.HasPrefix(arg, clusterCIDRFlag) {
+					return strings.TrimPrefix(arg, clusterCIDRFlag), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// podCIDRFromNodes returns the pod CIDRs of all nodes comma separated. It fails if any node has
+// no pod CIDR, as the pods on it would be missing from the result.
+func podCIDRFromNodes(ctx context.Context, kubeClient kubernetes.Interface) (string, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("list nodes: %w", err)
+	}
+
+	var cidrs []string
+	for _, node := range nodes.Items {
+		nodeCIDRs := node.Spec.PodCIDRs
+		if len(nodeCIDRs) == 0 && node.Spec.PodCIDR != "" {
+			nodeCIDRs = []string{node.Spec.PodCIDR}
+		}
+		if len(nodeCIDRs) == 0 {
+			return "", fmt.Errorf("couldn't discover the pod cidr, node %s has no pod cidr assigned and the kube-controller-manager has no %s flag", node.Name, strings.TrimSuffix(clusterCIDRFlag, "="))
+		}
+
+		for _, cidr := range nodeCIDRs {
+			if !slices.Contains(cidrs, cidr) {
+				cidrs = append(cidrs, cidr)
+			}
+		}
+	}
+	if len(cidrs) == 0 {
+		return "", fmt.Errorf("couldn't discover the pod cidr, there are no nodes and the kube-controller-manager has no %s flag", strings.TrimSuffix(clusterCIDRFlag, "="))
+	}
+
+	return strings.Join(cidrs, ","), nil
+}
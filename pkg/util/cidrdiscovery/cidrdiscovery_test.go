@@ -0,0 +1,113 @@
+package cidrdiscovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestGetPodCIDR(t *testing.T) {
+	controllerManager := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-controller-manager",
+			Namespace: metav1.NamespaceSystem,
+			Labels:    map[string]string{"component": "kube-controller-manager"},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:    "kube-controller-manager",
+			Command: []string{"kube-controller-manager", "--allocate-node-cidrs=true", "--cluster-cidr=10.244.0.0/16"},
+		}}},
+	}
+
+	tests := []struct {
+		name        string
+		objects     []runtime.Object
+		forbidPods  bool
+		expected    string
+		cached      bool
+		expectError bool
+	}{
+		{
+			name: "node pod cidrs",
+			objects: []runtime.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: corev1.NodeSpec{PodCIDR: "10.244.0.0/24"}},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}, Spec: corev1.NodeSpec{PodCIDR: "10.244.1.0/24"}},
+			},
+			expected: "10.244.0.0/24,10.244.1.0/24",
+		},
+		{
+			name: "dual-stack node pod cidrs",
+			objects: []runtime.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: corev1.NodeSpec{PodCIDR: "10.244.0.0/24", PodCIDRs: []string{"10.244.0.0/24", "fd00:10:244::/64"}}},
+			},
+			expected: "10.244.0.0/24,fd00:10:244::/64",
+		},
+		{
+			name: "kube-controller-manager flag",
+			objects: []runtime.Object{
+				controllerManager,
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: corev1.NodeSpec{PodCIDR: "10.244.1.0/24"}},
+			},
+			expected: "10.244.0.0/16",
+			cached:   true,
+		},
+		{
+			name: "kube-controller-manager pods are forbidden",
+			objects: []runtime.Object{
+				controllerManager,
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: corev1.NodeSpec{PodCIDR: "10.244.1.0/24"}},
+			},
+			forbidPods: true,
+			expected:   "10.244.1.0/24",
+		},
+		{
+			name: "node without pod cidr",
+			objects: []runtime.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: corev1.NodeSpec{PodCIDR: "10.244.0.0/24"}},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+			},
+			expectError: true,
+		},
+		{
+			name:        "no nodes",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			podCIDR = ""
+			kubeClient := fake.NewSimpleClientset(test.objects...)
+			if test.forbidPods {
+				kubeClient.PrependReactor("list", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewForbidden(corev1.Resource("pods"), "", nil)
+				})
+			}
+
+			cidr, err := GetPodCIDR(context.Background(), kubeClient)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, cidr)
+
+			// only the cluster cidr of the kube-controller-manager is cached, as nodes come and go
+			cidr, err = GetPodCIDR(context.Background(), fake.NewSimpleClientset())
+			if test.cached {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, cidr)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
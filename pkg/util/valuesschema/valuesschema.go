@@ -0,0 +1,294 @@
+// Package valuesschema validates helm values against the values.schema.json of a chart. Only the
+// subset of JSON schema that is used by chart values schemas is supported: $ref, type, enum, const,
+// required, properties, patternProperties, additionalProperties, items, allOf, anyOf and oneOf.
+// All other keywords are ignored and oneOf is treated like anyOf.
+package valuesschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDepth prevents endless recursion with recursive $refs
+const maxDepth = 100
+
+// Validate validates the values against the JSON schema and returns the violations. An error is only
+// returned if the schema or the values can't be parsed.
+func Validate(schema string, values interface{}) ([]string, error) {
+	var root interface{}
+	err := json.Unmarshal([]byte(schema), &root)
+	if err != nil {
+		return nil, fmt.Errorf("parse values schema: %w", err)
+	}
+
+	// normalize the values to the types of encoding/json
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshal values: %w", err)
+	}
+	var normalized interface{}
+	err = json.Unmarshal(raw, &normalized)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal values: %w", err)
+	}
+
+	v := &validator{root: root}
+	v.validate(root, normalized, "", 0)
+	return v.violations, nil
+}
+
+type validator struct {
+	root       interface{}
+	violations []string
+}
+
+func (v *validator) violation(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "(root)"
+	}
+
+	v.violations = append(v.violations, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) validate(schema interface{}, value interface{}, path string, depth int) {
+	if depth > maxDepth {
+		v.violation(path, "schema is nested too deep")
+		return
+	}
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.violation(path, "is not allowed")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObject(s, value, path, depth)
+	}
+}
+
+func (v *validator) validateObject(schema map[string]interface{}, value interface{}, path string, depth int) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := v.resolve(ref)
+		if err != nil {
+			v.violation(path, "%v", err)
+			return
+		}
+
+		v.validate(resolved, value, path, depth+1)
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			v.validate(sub, value, path, depth+1)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[keyword].([]interface{}); ok && !v.matchesAny(options, value, path, depth) {
+			v.violation(path, "does not match any of the allowed schemas")
+		}
+	}
+
+	if types, ok := schemaTypes(schema["type"]); ok && !matchesType(types, value) {
+		v.violation(path, "must be of type %s, but is %s", strings.Join(types, " or "), valueType(value))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !contains(enum, value) {
+		v.violation(path, "must be one of %s", formatValues(enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		v.violation(path, "must be %s", formatValues([]interface{}{constant}))
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		v.validateProperties(schema, typed, path, depth)
+	case []interface{}:
+		v.validateItems(schema, typed, path, depth)
+	}
+}
+
+func (v *validator) validateProperties(schema map[string]interface{}, value map[string]interface{}, path string, depth int) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, exists := value[key]; !exists {
+					v.violation(path, "missing required property %s", key)
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additionalProperties, hasAdditionalProperties := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		propertyPath := joinPath(path, key)
+		matched := false
+		if property, ok := properties[key]; ok {
+			v.validate(property, value[key], propertyPath, depth+1)
+			matched = true
+		}
+		for pattern, property := range patternProperties {
+			regEx, err := regexp.Compile(pattern)
+			if err != nil || !regEx.MatchString(key) {
+				continue
+			}
+
+			v.validate(property, value[key], propertyPath, depth+1)
+			matched = true
+		}
+		if matched || !hasAdditionalProperties {
+			continue
+		}
+
+		if allowed, ok := additionalProperties.(bool); ok && !allowed {
+			v.violation(propertyPath, "unknown property")
+			continue
+		}
+		v.validate(additionalProperties, value[key], propertyPath, depth+1)
+	}
+}
+
+func (v *validator) validateItems(schema map[string]interface{}, value []interface{}, path string, depth int) {
+	switch items := schema["items"].(type) {
+	case []interface{}:
+		for i := 0; i < len(items) && i < len(value); i++ {
+			v.validate(items[i], value[i], path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+	case map[string]interface{}, bool:
+		for i := range value {
+			v.validate(items, value[i], path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+	}
+}
+
+// matchesAny checks if the value matches at least one of the schemas without reporting the
+// violations of the schemas that don't match
+func (v *validator) matchesAny(schemas []interface{}, value interface{}, path string, depth int) bool {
+	for _, schema := range schemas {
+		sub := &validator{root: v.root}
+		sub.validate(schema, value, path, depth+1)
+		if len(sub.violations) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolve returns the schema a local $ref such as #/$defs/Name points to
+func (v *validator) resolve(ref string) (interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %s, only local references are supported", ref)
+	}
+
+	current := v.root
+	for _, token := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if token == "" {
+			continue
+		}
+
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("can not resolve $ref %s", ref)
+		}
+		current, ok = currentMap[token]
+		if !ok {
+			return nil, fmt.Errorf("can not resolve $ref %s", ref)
+		}
+	}
+
+	return current, nil
+}
+
+func schemaTypes(schemaType interface{}) ([]string, bool) {
+	switch t := schemaType.(type) {
+	case string:
+		return []string{t}, true
+	case []interface{}:
+		types := []string{}
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types, len(types) > 0
+	}
+
+	return nil, false
+}
+
+func matchesType(types []string, value interface{}) bool {
+	actual := valueType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func valueType(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if typed == math.Trunc(typed) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatValues(values []interface{}) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		raw, _ := json.Marshal(value)
+		formatted = append(formatted, string(raw))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
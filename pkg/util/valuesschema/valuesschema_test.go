@@ -0,0 +1,135 @@
+package valuesschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "controlPlane": {
+      "$ref": "#/$defs/ControlPlane"
+    },
+    "labels": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    },
+    "plugins": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {
+            "type": "string"
+          }
+        }
+      }
+    },
+    "replicas": {
+      "anyOf": [
+        {"type": "integer"},
+        {"type": "string", "enum": ["auto"]}
+      ]
+    }
+  },
+  "$defs": {
+    "ControlPlane": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "service": {
+          "type": "object",
+          "properties": {
+            "type": {
+              "enum": ["ClusterIP", "NodePort", "LoadBalancer"]
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     map[string]interface{}
+		violations []string
+	}{
+		{
+			name: "valid",
+			values: map[string]interface{}{
+				"controlPlane": map[string]interface{}{
+					"service": map[string]interface{}{"type": "LoadBalancer"},
+				},
+				"labels":   map[string]interface{}{"team": "a"},
+				"plugins":  []interface{}{map[string]interface{}{"name": "hooks"}},
+				"replicas": 3,
+			},
+		},
+		{
+			name: "unknown property",
+			values: map[string]interface{}{
+				"controlPlane": map[string]interface{}{
+					"servce": map[string]interface{}{"type": "LoadBalancer"},
+				},
+			},
+			violations: []string{"controlPlane.servce: unknown property"},
+		},
+		{
+			name:       "unknown top level property",
+			values:     map[string]interface{}{"sync": map[string]interface{}{}},
+			violations: []string{"sync: unknown property"},
+		},
+		{
+			name: "enum",
+			values: map[string]interface{}{
+				"controlPlane": map[string]interface{}{
+					"service": map[string]interface{}{"type": "Ingress"},
+				},
+			},
+			violations: []string{`controlPlane.service.type: must be one of "ClusterIP", "NodePort", "LoadBalancer"`},
+		},
+		{
+			name:       "additional properties schema",
+			values:     map[string]interface{}{"labels": map[string]interface{}{"team": 1}},
+			violations: []string{"labels.team: must be of type string, but is integer"},
+		},
+		{
+			name:       "array items",
+			values:     map[string]interface{}{"plugins": []interface{}{map[string]interface{}{}}},
+			violations: []string{"plugins[0]: missing required property name"},
+		},
+		{
+			name:       "any of",
+			values:     map[string]interface{}{"replicas": "many"},
+			violations: []string{"replicas: does not match any of the allowed schemas"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := Validate(testSchema, tt.values)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.violations, violations)
+		})
+	}
+}
+
+func TestValidateInvalidSchema(t *testing.T) {
+	_, err := Validate("{", map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestValidateRecursiveRef(t *testing.T) {
+	violations, err := Validate(`{"$ref": "#"}`, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"(root): schema is nested too deep"}, violations)
+}
@@ -0,0 +1,195 @@
+package vclustervalues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Parse parses the given helm values yaml into a map
+func Parse(values string) (map[string]interface{}, error) {
+	parsed := map[string]interface{}{}
+	if values == "" {
+		return parsed, nil
+	}
+
+	err := yaml.Unmarshal([]byte(values), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("parse helm values: %w", err)
+	}
+	if parsed == nil {
+		parsed = map[string]interface{}{}
+	}
+
+	return parsed, nil
+}
+
+// MergeStrategy defines how a list in the overrides is merged with an existing list
+type MergeStrategy string
+
+const (
+	// MergeStrategyReplace replaces the existing list, this is the default
+	MergeStrategyReplace MergeStrategy = "replace"
+	// MergeStrategyAppend appends the items of the override to the existing list
+	MergeStrategyAppend MergeStrategy = "append"
+)
+
+// Merge merges the overrides into the given helm values yaml and returns the resulting yaml.
+// Maps are merged recursively, all other values in overrides replace the existing ones.
+func Merge(values string, overrides map[string]interface{}) (string, error) {
+	return MergeWithStrategies(values, overrides, nil)
+}
+
+// MergeWithStrategies works like Merge, but lists at the dot separated paths in strategies
+// (e.g. sync.toHost.ingresses.patches) are merged according to their MergeStrategy.
+// The merge works on the yaml nodes, so comments and key order of the values are preserved.
+func MergeWithStrategies(values string, overrides map[string]interface{}, strategies map[string]MergeStrategy) (string, error) {
+	if len(overrides) == 0 {
+		return values, nil
+	}
+
+	// make sure the values are a valid helm values map
+	_, err := Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	overridesNode, err := toNode(overrides)
+	if err != nil {
+		return "", err
+	}
+
+	document := &yamlv3.Node{}
+	err = yamlv3.Unmarshal([]byte(values), document)
+	if err != nil {
+		return "", fmt.Errorf("parse helm values: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yamlv3.MappingNode {
+		document = &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{overridesNode}}
+	} else {
+		mergeNodes(document.Content[0], overridesNode, strategies, "")
+	}
+
+	out := &bytes.Buffer{}
+	encoder := yamlv3.NewEncoder(out)
+	encoder.SetIndent(2)
+	err = encoder.Encode(document)
+	if err != nil {
+		return "", fmt.Errorf("marshal helm values: %w", err)
+	}
+	err = encoder.Close()
+	if err != nil {
+		return "", fmt.Errorf("marshal helm values: %w", err)
+	}
+
+	return out.String(), nil
+}
+
+// Append appends the items to the list at the given path in the helm values yaml and returns the resulting yaml.
+// A missing list is created, any other existing value at the path is replaced.
+func Append(values string, items []interface{}, path ...string) (string, error) {
+	if len(items) == 0 || len(path) == 0 {
+		return values, nil
+	}
+
+	parsed, err := Parse(values)
+	if err != nil {
+		return "", err
+	}
+
+	existing, _ := Lookup(parsed, path...).([]interface{})
+	var override interface{} = append(append([]interface{}{}, existing...), items...)
+	for i := len(path) - 1; i > 0; i-- {
+		override = map[string]interface{}{path[i]: override}
+	}
+
+	return Merge(values, map[string]interface{}{path[0]: override})
+}
+
+// Lookup returns the value at the given path or nil if it doesn't exist
+func Lookup(values map[string]interface{}, path ...string) interface{} {
+	var current interface{} = values
+	for _, key := range path {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		current = currentMap[key]
+	}
+
+	return current
+}
+
+// mergeNodes merges the override mapping node into the base mapping node. Maps are merged
+// recursively, all other values replace the existing ones unless a strategy says otherwise.
+func mergeNodes(base, overrides *yamlv3.Node, strategies map[string]MergeStrategy, path string) {
+	for i := 0; i+1 < len(overrides.Content); i += 2 {
+		key, value := overrides.Content[i], overrides.Content[i+1]
+		keyPath := strings.TrimPrefix(path+"."+key.Value, ".")
+
+		existing := -1
+		for j := 0; j+1 < len(base.Content); j += 2 {
+			if base.Content[j].Value == key.Value {
+				existing = j + 1
+				break
+			}
+		}
+		if existing == -1 {
+			base.Content = append(base.Content, key, value)
+			continue
+		}
+
+		baseValue := base.Content[existing]
+		switch {
+		case baseValue.Kind == yamlv3.MappingNode && value.Kind == yamlv3.MappingNode:
+			mergeNodes(baseValue, value, strategies, keyPath)
+		case baseValue.Kind == yamlv3.SequenceNode && value.Kind == yamlv3.SequenceNode && strategies[keyPath] == MergeStrategyAppend:
+			baseValue.Content = append(baseValue.Content, value.Content...)
+		default:
+			value.HeadComment = baseValue.HeadComment
+			value.LineComment = baseValue.LineComment
+			value.FootComment = baseValue.FootComment
+			base.Content[existing] = value
+		}
+	}
+}
+
+// toNode converts the values into a yaml node. The values are encoded as json first, so
+// numbers are written the same way as by the json based yaml library.
+func toNode(values map[string]interface{}) (*yamlv3.Node, error) {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshal helm values: %w", err)
+	}
+
+	document := &yamlv3.Node{}
+	err = yamlv3.Unmarshal(raw, document)
+	if err != nil {
+		return nil, fmt.Errorf("parse helm values: %w", err)
+	}
+
+	node := document.Content[0]
+	resetStyle(node)
+	return node, nil
+}
+
+// resetStyle removes the json flow style, so the nodes are written in block style. Strings stay
+// quoted if helm would read them as something else without quotes, e.g. y or on.
+func resetStyle(node *yamlv3.Node) {
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!str" {
+		var plain interface{}
+		if yaml.Unmarshal([]byte(node.Value), &plain) != nil || plain != node.Value {
+			return
+		}
+	}
+
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
@@ -0,0 +1,78 @@
+package vclustervalues
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeWithStrategies(t *testing.T) {
+	values := "sync:\n  toHost:\n    ingresses:\n      enabled: false\n      patches:\n      - path: a\ninit:\n  manifests:\n  - x\n"
+	overrides := map[string]interface{}{
+		"sync": map[string]interface{}{
+			"toHost": map[string]interface{}{
+				"ingresses": map[string]interface{}{
+					"enabled": true,
+					"patches": []interface{}{map[string]interface{}{"path": "b"}},
+				},
+			},
+		},
+		"init": map[string]interface{}{
+			"manifests": []interface{}{"y"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		strategies map[string]MergeStrategy
+		patches    []interface{}
+		manifests  []interface{}
+	}{
+		{
+			name:      "replace by default",
+			patches:   []interface{}{map[string]interface{}{"path": "b"}},
+			manifests: []interface{}{"y"},
+		},
+		{
+			name:       "append for nominated path",
+			strategies: map[string]MergeStrategy{"sync.toHost.ingresses.patches": MergeStrategyAppend},
+			patches:    []interface{}{map[string]interface{}{"path": "a"}, map[string]interface{}{"path": "b"}},
+			manifests:  []interface{}{"y"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, err := MergeWithStrategies(values, overrides, test.strategies)
+			assert.NoError(t, err)
+
+			parsed, err := Parse(merged)
+			assert.NoError(t, err)
+			assert.Equal(t, true, Lookup(parsed, "sync", "toHost", "ingresses", "enabled"))
+			assert.Equal(t, test.patches, Lookup(parsed, "sync", "toHost", "ingresses", "patches"))
+			assert.Equal(t, test.manifests, Lookup(parsed, "init", "manifests"))
+		})
+	}
+}
+
+func TestMergePreservesCommentsAndOrder(t *testing.T) {
+	values := "# the distro of the vcluster\ncontrolPlane:\n  distro:\n    k3s:\n      enabled: true # use k3s\nsync:\n  toHost:\n    pods:\n      enabled: true\n"
+
+	merged, err := Merge(values, map[string]interface{}{
+		"controlPlane": map[string]interface{}{
+			"statefulSet": map[string]interface{}{
+				"scheduling": map[string]interface{}{"priorityClassName": "high"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, merged, "# the distro of the vcluster\n")
+	assert.Contains(t, merged, "enabled: true # use k3s\n")
+	assert.Less(t, strings.Index(merged, "controlPlane:"), strings.Index(merged, "sync:"))
+	assert.Less(t, strings.Index(merged, "distro:"), strings.Index(merged, "statefulSet:"))
+
+	parsed, err := Parse(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, "high", Lookup(parsed, "controlPlane", "statefulSet", "scheduling", "priorityClassName"))
+}
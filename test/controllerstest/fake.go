@@ -1,6 +1,10 @@
 package controllerstest
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,6 +15,8 @@ import (
 	fakeclientset "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	restfake "k8s.io/client-go/rest/fake"
+
+	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
 )
 
 type fakeConfigGetter struct {
@@ -22,12 +28,75 @@ func (f *fakeConfigGetter) NewForConfig(_ *rest.Config) (kubernetes.Interface, e
 }
 
 type fakeHTTPClientGetter struct {
+	// path is the only path that responds, defaults to all paths
+	path string
+	// statusCode is the response status code, defaults to 200
+	statusCode int
+	// body is the response body, defaults to "ok"
+	body *string
+	// urls records the requested urls
+	urls []string
 }
 
 func (f *fakeHTTPClientGetter) ClientFor(_ http.RoundTripper, _ time.Duration) *http.Client {
-	return restfake.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+	return restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+		f.urls = append(f.urls, req.URL.String())
 		recorder := httptest.NewRecorder()
-		fmt.Fprint(recorder, "ok")
+		if f.path != "" && req.URL.Path != f.path {
+			recorder.WriteHeader(http.StatusNotFound)
+			return recorder.Result(), nil
+		}
+		if f.statusCode != 0 {
+			recorder.WriteHeader(f.statusCode)
+		}
+		if f.body != nil {
+			fmt.Fprint(recorder, *f.body)
+		} else {
+			fmt.Fprint(recorder, "ok")
+		}
 		return recorder.Result(), nil
 	})
 }
+
+type fakeChartMetadataGetter struct {
+	kubeVersion string
+}
+
+func (f *fakeChartMetadataGetter) ChartMetadata(_ context.Context, _, name, version string) (*helm.Metadata, error) {
+	return &helm.Metadata{Name: name, Version: version, KubeVersion: f.kubeVersion}, nil
+}
+
+type fakeValuesSchemaGetter struct {
+	schema string
+}
+
+func (f *fakeValuesSchemaGetter) ValuesSchema(_ context.Context, _, _, _ string) (string, error) {
+	return f.schema, nil
+}
+
+// chartArchive returns a gzipped chart archive with the given files
+func chartArchive(files map[string]string) ([]byte, error) {
+	archive := &bytes.Buffer{}
+	gz := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			return nil, err
+		}
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return archive.Bytes(), nil
+}
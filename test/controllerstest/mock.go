@@ -1,35 +1,77 @@
 package controllerstest
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
 	"github.com/loft-sh/cluster-api-provider-vcluster/pkg/helm"
 	"github.com/stretchr/testify/mock"
 )
 
 type MockHelmClient struct {
 	mock.Mock
+
+	// UpgradeOptions are the options of the last upgrade
+	UpgradeOptions helm.UpgradeOptions
+	// RollbackRevision is the revision of the last rollback
+	RollbackRevision string
 }
 
-func (m *MockHelmClient) Install(_, _ string, _ helm.UpgradeOptions) error {
+func (m *MockHelmClient) Install(_ context.Context, _, _ string, _ helm.UpgradeOptions) error {
 	args := m.Called()
 	return args.Error(0)
 }
 
-func (m *MockHelmClient) Upgrade(_, _ string, _ helm.UpgradeOptions) error {
+func (m *MockHelmClient) Upgrade(_ context.Context, _, _ string, options helm.UpgradeOptions) error {
+	m.UpgradeOptions = options
 	args := m.Called()
 	return args.Error(0)
 }
 
-func (m *MockHelmClient) Rollback(_, _ string, _ string) error {
+func (m *MockHelmClient) Rollback(_ context.Context, _, _ string, revision string) error {
+	m.RollbackRevision = revision
 	args := m.Called()
 	return args.Error(0)
 }
 
-func (m *MockHelmClient) Delete(_, _ string) error {
+func (m *MockHelmClient) Delete(_ context.Context, _, _ string) error {
 	args := m.Called()
 	return args.Error(0)
 }
 
-func (m *MockHelmClient) Exists(_, _ string) (bool, error) {
+func (m *MockHelmClient) Exists(_ context.Context, _, _ string) (bool, error) {
 	args := m.Called()
 	return args.Bool(0), args.Error(1)
 }
+
+func (m *MockHelmClient) Template(_ context.Context, _, _ string, _ helm.UpgradeOptions) ([]byte, error) {
+	args := m.Called()
+	manifests, _ := args.Get(0).([]byte)
+	return manifests, args.Error(1)
+}
+
+// concurrencyHelmClient fails every upgrade after a short delay and records how many upgrades ran at the same time
+type concurrencyHelmClient struct {
+	MockHelmClient
+
+	calls     int32
+	active    int32
+	maxActive int32
+}
+
+func (c *concurrencyHelmClient) Upgrade(_ context.Context, _, _ string, _ helm.UpgradeOptions) error {
+	atomic.AddInt32(&c.calls, 1)
+	active := atomic.AddInt32(&c.active, 1)
+	defer atomic.AddInt32(&c.active, -1)
+	for {
+		maxActive := atomic.LoadInt32(&c.maxActive)
+		if active <= maxActive || atomic.CompareAndSwapInt32(&c.maxActive, maxActive, active) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	return errors.New("upgrade failed")
+}